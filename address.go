@@ -1,17 +1,121 @@
 package belajargorm
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// AddressType adalah kegunaan alamat
+type AddressType string
+
+const (
+	AddressHome     AddressType = "home"
+	AddressBilling  AddressType = "billing"
+	AddressShipping AddressType = "shipping"
+)
+
+var (
+	ErrAddressNotFound    = errors.New("belajargorm: address not found")
+	ErrInvalidAddressType = errors.New("belajargorm: invalid address type")
+	ErrDuplicateAddress   = errors.New("belajargorm: duplicate address")
+)
+
+// Address menyimpan alamat asli di kolom address dan bagian-bagiannya di AddressFields
+// ParseConfidence adalah Confidence dari ParseAddress, bernilai 1 jika bagian alamat diisi langsung
+// setiap user punya paling banyak satu alamat primary per Type (idx_addresses_primary),
+// ganti alamat primary lewat AddressService.SetPrimaryAddress
+// idx_addresses_primary dibuat oleh migration 13, tidak ditulis di tag karena AutoMigrate di mysql membuatnya tanpa where
+//
+// Fingerprint dihitung dari Fields saat create, lihat AddressFields.Fingerprint
+// tambahkan alamat lewat AddressService.AddAddress agar alamat yang sama tidak tersimpan dua kali
+//
+// Latitude dan Longitude kosong (NULL) jika alamat belum diketahui koordinatnya, lihat GeocodePlugin
+// dan AddressService.AddressesWithin
 type Address struct {
-	ID        int64     `gorm:"primary_key;column:id;autoIncrement"`
-	UserId    string    `gorm:"column:user_id"`
-	Address   string    `gorm:"column:address"`
-	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
-	User      User      `gorm:"foreignKey:user_id;references:id"`
+	ID              int64         `gorm:"primaryKey;column:id;autoIncrement"`
+	UserId          string        `gorm:"column:user_id;size:100;index:idx_addresses_fingerprint,priority:1"`
+	Type            AddressType   `gorm:"column:type;size:20;not null;default:'home'"`
+	IsPrimary       bool          `gorm:"column:is_primary;not null;default:false"`
+	Address         string        `gorm:"column:address;size:100"`
+	Fields          AddressFields `gorm:"embedded"`
+	ParseConfidence float64       `gorm:"column:parse_confidence;not null;default:0"`
+	Fingerprint     string        `gorm:"column:fingerprint;size:64;index:idx_addresses_fingerprint,priority:2"`
+	Latitude        *float64      `gorm:"column:latitude;index:idx_addresses_location,priority:1"`
+	Longitude       *float64      `gorm:"column:longitude;index:idx_addresses_location,priority:2"`
+	CreatedAt       time.Time     `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time     `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	User            User          `gorm:"foreignKey:user_id;references:id"`
 	// kolom user_id menjadi foreign key yang merujuk pada kolom id di tabel users
 }
 
 func (a *Address) TableName() string {
 	return "addresses"
 }
+
+func validAddressType(t AddressType) bool {
+	return t == AddressHome || t == AddressBilling || t == AddressShipping
+}
+
+// hook BeforeCreate melengkapi alamat dengan complete
+// alamat pertama user untuk setiap Type otomatis menjadi primary
+func (a *Address) BeforeCreate(tx *gorm.DB) error {
+	if a.Type == "" {
+		a.Type = AddressHome
+	}
+	if !validAddressType(a.Type) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddressType, a.Type)
+	}
+	if err := a.claimPrimary(tx); err != nil {
+		return err
+	}
+	a.complete()
+	return nil
+}
+
+// complete melengkapi salah satu sisi alamat lalu menghitung Fingerprint
+// jika hanya Address yang diisi maka Fields diisi dengan ParseAddress,
+// jika hanya Fields yang diisi maka Address disusun dari Fields
+func (a *Address) complete() {
+	switch {
+	case a.Fields.IsZero() && a.Address != "":
+		parsed := ParseAddress(a.Address)
+		a.Fields, a.ParseConfidence = parsed.AddressFields, parsed.Confidence
+	case !a.Fields.IsZero():
+		if a.Address == "" {
+			a.Address = truncate(a.Fields.String(), 100)
+		}
+		if a.ParseConfidence == 0 {
+			a.ParseConfidence = 1
+		}
+	}
+	if a.Fields.Country == "" {
+		a.Fields.Country = "ID"
+	}
+	a.Fingerprint = a.Fields.Fingerprint()
+}
+
+// claimPrimary sama seperti Wallet.claimDefault, hanya alamat pertama per Type di satu batch yang menjadi primary
+func (a *Address) claimPrimary(tx *gorm.DB) error {
+	if a.UserId == "" {
+		return nil
+	}
+	key := "belajargorm:primary_address:" + a.UserId + ":" + string(a.Type)
+	if _, claimed := tx.Statement.Settings.Load(key); claimed {
+		return nil
+	}
+	if !a.IsPrimary {
+		var count int64
+		err := tx.Model(&Address{}).Where("user_id = ? AND type = ? AND is_primary = ?", a.UserId, a.Type, true).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		a.IsPrimary = count == 0
+	}
+	if a.IsPrimary {
+		tx.Statement.Settings.Store(key, true)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package belajargorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DuplicateAddressGroup adalah sekumpulan alamat milik satu user yang dianggap sama
+// KeepID adalah alamat yang dipertahankan, yaitu alamat primary jika ada atau alamat paling lama
+type DuplicateAddressGroup struct {
+	UserID       string
+	Type         AddressType
+	KeepID       int64
+	DuplicateIDs []int64
+}
+
+func (g DuplicateAddressGroup) String() string {
+	ids := make([]string, len(g.DuplicateIDs))
+	for i, id := range g.DuplicateIDs {
+		ids[i] = fmt.Sprint(id)
+	}
+	return fmt.Sprintf("user %s %s address %d: duplicates %s", g.UserID, g.Type, g.KeepID, strings.Join(ids, ", "))
+}
+
+// DedupeReport adalah hasil DedupeAddresses
+type DedupeReport struct {
+	CheckedAddresses int
+	Groups           []DuplicateAddressGroup
+	// Merged bernilai true jika duplikat sudah digabung dan dihapus
+	Merged bool
+}
+
+func (r *DedupeReport) String() string {
+	lines := []string{fmt.Sprintf("%d addresses checked, %d duplicate groups", r.CheckedAddresses, len(r.Groups))}
+	for _, group := range r.Groups {
+		lines = append(lines, group.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DedupeOptions mengatur DedupeAddresses
+type DedupeOptions struct {
+	// Merge menggabungkan setiap group ke alamat KeepID lalu menghapus duplikatnya
+	Merge bool
+}
+
+// DedupeAddresses mencari alamat yang sama (lihat AddAddress) di data yang sudah ada, satu user setiap kali
+// tanpa opts.Merge hanya melaporkan, dengan opts.Merge setiap user diproses di transaction sendiri:
+// bagian alamat yang kosong dilengkapi dari duplikatnya, duplikat dihapus,
+// dan Fingerprint yang belum sesuai dengan AddressFields dihitung ulang
+// perubahan dan penghapusan dicatat oleh AuditPlugin, actor diambil dari context (WithActor)
+func (s *AddressService) DedupeAddresses(ctx context.Context, opts DedupeOptions) (*DedupeReport, error) {
+	var users []string
+	err := s.db.WithContext(ctx).Model(&Address{}).Where("user_id IS NOT NULL").
+		Distinct("user_id").Order("user_id").Pluck("user_id", &users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DedupeReport{Merged: opts.Merge}
+	for _, userID := range users {
+		if !opts.Merge {
+			checked, groups, err := dedupeUser(s.db.WithContext(ctx), userID, false)
+			if err != nil {
+				return report, err
+			}
+			report.CheckedAddresses += checked
+			report.Groups = append(report.Groups, groups...)
+			continue
+		}
+
+		var checked int
+		var groups []DuplicateAddressGroup
+		err := s.transaction(ctx, func(tx *gorm.DB) error {
+			var err error
+			checked, groups, err = dedupeUser(tx, userID, true)
+			return err
+		})
+		if err != nil {
+			return report, err
+		}
+		report.CheckedAddresses += checked
+		report.Groups = append(report.Groups, groups...)
+	}
+	return report, nil
+}
+
+// dedupeUser mengelompokkan alamat userID, dengan merge group langsung digabung
+func dedupeUser(tx *gorm.DB, userID string, merge bool) (int, []DuplicateAddressGroup, error) {
+	query := tx.Where("user_id = ?", userID).Order("type, id")
+	if merge {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var addresses []Address
+	if err := query.Find(&addresses).Error; err != nil {
+		return 0, nil, err
+	}
+
+	// setiap alamat masuk ke group pertama yang semua anggotanya sama dengan alamat tersebut,
+	// sehingga bagian alamat yang terisi tidak pernah bertentangan di dalam satu group
+	// misalnya "Jl. Sudirman" tidak menggabungkan "Jl. Sudirman No. 12" dan "Jl. Sudirman No. 14"
+	// alamat diurutkan dari yang paling lama
+	var groups [][]*Address
+	for i := range addresses {
+		address := &addresses[i]
+		joined := false
+		for g, group := range groups {
+			if duplicateOfAll(group, address) {
+				groups[g] = append(group, address)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			groups = append(groups, []*Address{address})
+		}
+	}
+
+	var result []DuplicateAddressGroup
+	for _, group := range groups {
+		keep := group[0]
+		for _, address := range group {
+			if address.IsPrimary {
+				keep = address
+			}
+		}
+
+		if len(group) > 1 {
+			duplicate := DuplicateAddressGroup{UserID: userID, Type: keep.Type, KeepID: keep.ID}
+			fields := keep.Fields
+			for _, address := range group {
+				if address != keep {
+					duplicate.DuplicateIDs = append(duplicate.DuplicateIDs, address.ID)
+					fields = fields.mergeFrom(address.Fields)
+				}
+			}
+			result = append(result, duplicate)
+
+			if merge {
+				if err := tx.Where("id IN ?", duplicate.DuplicateIDs).Delete(&Address{}).Error; err != nil {
+					return 0, nil, err
+				}
+				if err := mergeAddress(tx, keep, fields); err != nil {
+					return 0, nil, err
+				}
+				continue
+			}
+		}
+
+		if merge && keep.Fingerprint != keep.Fields.Fingerprint() {
+			err := tx.Model(&Address{}).Where("id = ?", keep.ID).Update("fingerprint", keep.Fields.Fingerprint()).Error
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	return len(addresses), result, nil
+}
+
+// duplicateOfAll bernilai true jika address sama (lihat findDuplicate) dengan setiap alamat di group
+func duplicateOfAll(group []*Address, address *Address) bool {
+	for _, member := range group {
+		if member.Type != address.Type || findDuplicate([]Address{*member}, address) == nil {
+			return false
+		}
+	}
+	return true
+}
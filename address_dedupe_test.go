@@ -0,0 +1,130 @@
+package belajargorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeAddresses(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := WithActor(context.Background(), "1")
+
+	// seperti TestUserAndAddresses, db.Create tidak memeriksa duplikat
+	addresses := []Address{
+		{UserId: "2", Address: "Jalan A"},
+		{UserId: "2", Address: "Jalan B"},
+		{UserId: "2", Address: "JL. A, Bandung"},
+		{UserId: "2", Type: AddressBilling, Address: "Jalan A"},
+		{UserId: "3", Address: "Jalan A"},
+	}
+	assert.Nil(t, db.Create(&addresses).Error)
+	assert.Nil(t, service.SetPrimaryAddress(ctx, "2", addresses[2].ID))
+
+	report, err := service.DedupeAddresses(ctx, DedupeOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, report.CheckedAddresses)
+	assert.False(t, report.Merged)
+	if assert.Equal(t, 1, len(report.Groups)) {
+		// alamat primary yang dipertahankan
+		assert.Equal(t, addresses[2].ID, report.Groups[0].KeepID)
+		assert.Equal(t, []int64{addresses[0].ID}, report.Groups[0].DuplicateIDs)
+	}
+	var count int64
+	assert.Nil(t, db.Model(&Address{}).Count(&count).Error)
+	assert.Equal(t, int64(5), count)
+
+	report, err = service.DedupeAddresses(ctx, DedupeOptions{Merge: true})
+	assert.Nil(t, err)
+	assert.True(t, report.Merged)
+	assert.Equal(t, 1, len(report.Groups))
+
+	var remaining []Address
+	assert.Nil(t, db.Where("user_id = ?", "2").Order("id").Find(&remaining).Error)
+	assert.Equal(t, 3, len(remaining))
+	assert.Equal(t, addresses[1].ID, remaining[0].ID)
+	assert.Equal(t, addresses[2].ID, remaining[1].ID)
+	assert.True(t, remaining[1].IsPrimary)
+
+	// penghapusan dicatat oleh AuditPlugin
+	var logs []UserLog
+	assert.Nil(t, db.Where("entity = ? AND action = ?", "addresses", AuditDelete).Find(&logs).Error)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, "1", logs[0].ActorId)
+
+	report, err = service.DedupeAddresses(ctx, DedupeOptions{Merge: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(report.Groups))
+}
+
+func TestDedupeAddressesConflictingFields(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := WithActor(context.Background(), "1")
+
+	// "Jl. Sudirman" mirip dengan keduanya, tetapi No. 12 dan No. 14 adalah alamat berbeda
+	addresses := []Address{
+		{UserId: "2", Address: "Jl. Sudirman"},
+		{UserId: "2", Address: "Jl. Sudirman No. 12"},
+		{UserId: "2", Address: "Jl. Sudirman No. 14"},
+	}
+	assert.Nil(t, db.Create(&addresses).Error)
+
+	report, err := service.DedupeAddresses(ctx, DedupeOptions{Merge: true})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(report.Groups)) {
+		assert.Equal(t, addresses[0].ID, report.Groups[0].KeepID)
+		assert.Equal(t, []int64{addresses[1].ID}, report.Groups[0].DuplicateIDs)
+	}
+
+	var remaining []Address
+	assert.Nil(t, db.Where("user_id = ?", "2").Order("id").Find(&remaining).Error)
+	if assert.Equal(t, 2, len(remaining)) {
+		assert.Equal(t, "12", remaining[0].Fields.Number)
+		assert.Equal(t, "Jl. Sudirman", remaining[0].Address)
+		assert.Equal(t, remaining[0].Fields.Fingerprint(), remaining[0].Fingerprint)
+		assert.Equal(t, addresses[2].ID, remaining[1].ID)
+		assert.Equal(t, "Jl. Sudirman No. 14", remaining[1].Address)
+	}
+}
+
+func TestAddressFingerprintMigration(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	for {
+		done, err := migrator.Rollback(ctx, 1)
+		assert.Nil(t, err)
+		if len(done) == 0 || done[0].Version == 14 {
+			break
+		}
+	}
+	assert.False(t, db.Migrator().HasColumn(&Address{}, "fingerprint"))
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_primary"))
+	now := time.Now()
+	for _, street := range []string{"Jalan A", "Jl. A"} {
+		err := db.Table("addresses").Create(map[string]interface{}{
+			"user_id": "2", "address": street, "street": street, "country": "ID", "created_at": now, "updated_at": now,
+		}).Error
+		assert.Nil(t, err)
+	}
+
+	_, err := migrator.Apply(ctx)
+	assert.Nil(t, err)
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_fingerprint"))
+
+	var addresses []Address
+	assert.Nil(t, db.Order("id").Find(&addresses).Error)
+	assert.Equal(t, 2, len(addresses))
+	assert.NotEqual(t, "", addresses[0].Fingerprint)
+	assert.Equal(t, addresses[0].Fingerprint, addresses[1].Fingerprint)
+	// salinan di migration 14 masih sama dengan AddressFields.Fingerprint
+	assert.Equal(t, addresses[0].Fields.Fingerprint(), addresses[0].Fingerprint)
+}
@@ -0,0 +1,244 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddressVersion adalah isi Address yang berlaku pada periode [ValidFrom, ValidTo)
+// ValidTo kosong (NULL) untuk versi yang masih berlaku, setiap alamat punya paling banyak satu versi seperti itu
+// address_id sengaja tidak dibuat foreign key agar riwayat tetap ada setelah alamat dihapus
+type AddressVersion struct {
+	ID        int64         `gorm:"primaryKey;column:id;autoIncrement"`
+	AddressId int64         `gorm:"column:address_id;not null;index:idx_address_versions_validity,priority:1"`
+	UserId    string        `gorm:"column:user_id;size:100"`
+	Type      AddressType   `gorm:"column:type;size:20;not null"`
+	Address   string        `gorm:"column:address;size:100"`
+	Fields    AddressFields `gorm:"embedded"`
+	Latitude  *float64      `gorm:"column:latitude"`
+	Longitude *float64      `gorm:"column:longitude"`
+	ValidFrom time.Time     `gorm:"column:valid_from;not null;index:idx_address_versions_validity,priority:2"`
+	ValidTo   *time.Time    `gorm:"column:valid_to"`
+	ActorId   string        `gorm:"column:actor_id;size:100"`
+}
+
+func (v *AddressVersion) TableName() string {
+	return "address_versions"
+}
+
+// sameAs bernilai true jika isi alamat a sama dengan versi ini
+// kolom seperti is_primary dan fingerprint tidak membuat versi baru
+func (v *AddressVersion) sameAs(a *Address) bool {
+	return v.UserId == a.UserId && v.Type == a.Type && v.Address == a.Address && v.Fields == a.Fields &&
+		equalFloat(v.Latitude, a.Latitude) && equalFloat(v.Longitude, a.Longitude)
+}
+
+func equalFloat(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func newAddressVersion(a *Address, validFrom time.Time, actor string) AddressVersion {
+	return AddressVersion{
+		AddressId: a.ID,
+		UserId:    a.UserId,
+		Type:      a.Type,
+		Address:   a.Address,
+		Fields:    a.Fields,
+		Latitude:  a.Latitude,
+		Longitude: a.Longitude,
+		ValidFrom: validFrom,
+		ActorId:   actor,
+	}
+}
+
+// AddressAsOf mengembalikan isi alamat yang berlaku pada waktu at, misalnya alamat tujuan saat pesanan dibuat
+// ErrAddressNotFound jika alamat belum dibuat atau sudah dihapus pada waktu tersebut
+func (s *AddressService) AddressAsOf(ctx context.Context, addressID int64, at time.Time) (*AddressVersion, error) {
+	at = at.UTC()
+	var version AddressVersion
+	err := s.db.WithContext(ctx).
+		Where("address_id = ? AND valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)", addressID, at, at).
+		Order("valid_from DESC, id DESC").Take(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %d as of %s", ErrAddressNotFound, addressID, at.Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// AddressHistory mengembalikan semua versi alamat dari yang paling lama
+func (s *AddressService) AddressHistory(ctx context.Context, addressID int64) ([]AddressVersion, error) {
+	var versions []AddressVersion
+	err := s.db.WithContext(ctx).Where("address_id = ?", addressID).Order("valid_from, id").Find(&versions).Error
+	return versions, err
+}
+
+const addressHistoryBeforeKey = "belajargorm:address_history_before"
+
+// AddressHistoryPlugin menulis address_versions setiap kali Address dibuat, diubah atau dihapus,
+// termasuk lewat Updates dengan map, di transaction yang sama dengan perubahannya
+// hanya statement dengan model Address yang dicatat, perubahan lewat db.Table("addresses") atau db.Exec tidak
+// seperti AuditPlugin, plugin ini didaftarkan oleh Open kecuali Config.DisableAddressHistory bernilai true
+//
+//	db.Use(AddressHistoryPlugin{})
+type AddressHistoryPlugin struct{}
+
+func (AddressHistoryPlugin) Name() string {
+	return "belajargorm:address_history"
+}
+
+func (p AddressHistoryPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:create").Register("belajargorm:address_history_after_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("belajargorm:address_history_before_update", p.before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("belajargorm:address_history_after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("belajargorm:address_history_before_delete", p.before); err != nil {
+		return err
+	}
+	return callback.Delete().After("gorm:delete").Register("belajargorm:address_history_after_delete", p.afterDelete)
+}
+
+var addressType = reflect.TypeOf(Address{})
+
+func versioned(db *gorm.DB) bool {
+	return db.Error == nil && db.Statement.Schema != nil && db.Statement.Schema.ModelType == addressType
+}
+
+// before menyimpan id alamat yang terkena update/delete, karena kondisi where bisa berubah setelah update
+func (p AddressHistoryPlugin) before(db *gorm.DB) {
+	if !versioned(db) {
+		return
+	}
+	var conds []clause.Expression
+	if where, ok := db.Statement.Clauses["WHERE"]; ok {
+		conds = append(conds, where.Expression)
+	}
+	if pks := primaryKeys(db); len(pks) > 0 {
+		conds = append(conds, clause.IN{Column: clause.PrimaryColumn, Values: pks})
+	}
+	if len(conds) == 0 && !db.Statement.AllowGlobalUpdate {
+		return
+	}
+
+	var ids []int64
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Model(&Address{}).Clauses(conds...).Pluck("id", &ids).Error
+	if err != nil {
+		db.AddError(fmt.Errorf("belajargorm: address history: %w", err))
+		return
+	}
+	db.InstanceSet(addressHistoryBeforeKey, ids)
+}
+
+func addressHistoryBefore(db *gorm.DB) []int64 {
+	v, ok := db.InstanceGet(addressHistoryBeforeKey)
+	if !ok {
+		return nil
+	}
+	return v.([]int64)
+}
+
+func (p AddressHistoryPlugin) afterCreate(db *gorm.DB) {
+	if !versioned(db) {
+		return
+	}
+	var ids []int64
+	for _, pk := range primaryKeys(db) {
+		if id, ok := pk.(int64); ok {
+			ids = append(ids, id)
+		}
+	}
+	p.record(db, ids)
+}
+
+func (p AddressHistoryPlugin) afterUpdate(db *gorm.DB) {
+	if !versioned(db) {
+		return
+	}
+	p.record(db, addressHistoryBefore(db))
+}
+
+func (p AddressHistoryPlugin) afterDelete(db *gorm.DB) {
+	if !versioned(db) {
+		return
+	}
+	ids := addressHistoryBefore(db)
+	if len(ids) == 0 {
+		return
+	}
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Model(&AddressVersion{}).
+		Where("address_id IN ? AND valid_to IS NULL", ids).Update("valid_to", db.NowFunc().UTC()).Error
+	if err != nil {
+		db.AddError(fmt.Errorf("belajargorm: address history: %w", err))
+	}
+}
+
+// record menutup versi yang berlaku dan menulis versi baru untuk setiap alamat yang isinya berubah
+// alamat yang belum punya versi (baru dibuat) berlaku sejak CreatedAt
+func (p AddressHistoryPlugin) record(db *gorm.DB, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+	tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+
+	var addresses []Address
+	if err := tx.Where("id IN ?", ids).Order("id").Find(&addresses).Error; err != nil {
+		db.AddError(fmt.Errorf("belajargorm: address history: %w", err))
+		return
+	}
+	var open []AddressVersion
+	if err := tx.Where("address_id IN ? AND valid_to IS NULL", ids).Find(&open).Error; err != nil {
+		db.AddError(fmt.Errorf("belajargorm: address history: %w", err))
+		return
+	}
+	current := make(map[int64]*AddressVersion, len(open))
+	for i := range open {
+		current[open[i].AddressId] = &open[i]
+	}
+
+	now := db.NowFunc().UTC()
+	actor, _ := ActorFromContext(db.Statement.Context)
+	var closed []int64
+	var versions []AddressVersion
+	for i := range addresses {
+		address := &addresses[i]
+		version, ok := current[address.ID]
+		if !ok {
+			versions = append(versions, newAddressVersion(address, address.CreatedAt.UTC(), actor))
+			continue
+		}
+		if version.sameAs(address) {
+			continue
+		}
+		closed = append(closed, version.ID)
+		versions = append(versions, newAddressVersion(address, now, actor))
+	}
+
+	if len(closed) > 0 {
+		if err := tx.Model(&AddressVersion{}).Where("id IN ?", closed).Update("valid_to", now).Error; err != nil {
+			db.AddError(fmt.Errorf("belajargorm: address history: %w", err))
+			return
+		}
+	}
+	if len(versions) > 0 {
+		if err := tx.Create(&versions).Error; err != nil {
+			db.AddError(fmt.Errorf("belajargorm: address history: %w", err))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package belajargorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tick memberi jeda agar waktu sebelum dan sesudah perubahan berbeda, mysql menyimpan waktu dalam milidetik
+func tick() time.Time {
+	time.Sleep(10 * time.Millisecond)
+	at := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	return at
+}
+
+func TestAddressHistory(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := WithActor(context.Background(), "1")
+
+	beforeCreate := tick()
+	address := Address{UserId: "2", Address: "Jalan A"}
+	assert.Nil(t, db.WithContext(ctx).Create(&address).Error)
+	afterCreate := tick()
+
+	// Save dengan struct
+	address.Address = "Jalan B No. 2"
+	address.Fields = ParseAddress(address.Address).AddressFields
+	assert.Nil(t, db.WithContext(ctx).Save(&address).Error)
+	afterSave := tick()
+
+	// Updates dengan map, where memakai kolom yang ikut berubah
+	err := db.WithContext(ctx).Model(&Address{}).Where("user_id = ? AND street = ?", "2", "Jalan B").
+		Updates(map[string]interface{}{"street": "Jalan C", "address": "Jalan C No. 2"}).Error
+	assert.Nil(t, err)
+	afterUpdates := tick()
+
+	// perubahan primary tidak membuat versi baru
+	other := Address{UserId: "2", Address: "Jalan D"}
+	assert.Nil(t, db.Create(&other).Error)
+	assert.Nil(t, service.SetPrimaryAddress(ctx, "2", other.ID))
+
+	history, err := service.AddressHistory(ctx, address.ID)
+	assert.Nil(t, err)
+	if assert.Equal(t, 3, len(history)) {
+		assert.Equal(t, "Jalan A", history[0].Address)
+		assert.Equal(t, "Jalan B", history[1].Fields.Street)
+		assert.Equal(t, "Jalan C", history[2].Fields.Street)
+		assert.Equal(t, history[1].ValidFrom, *history[0].ValidTo)
+		assert.Equal(t, history[2].ValidFrom, *history[1].ValidTo)
+		assert.Nil(t, history[2].ValidTo)
+		assert.Equal(t, "1", history[2].ActorId)
+	}
+
+	_, err = service.AddressAsOf(ctx, address.ID, beforeCreate)
+	assert.ErrorIs(t, err, ErrAddressNotFound)
+	for at, expected := range map[time.Time]string{
+		afterCreate:  "Jalan A",
+		afterSave:    "Jalan B No. 2",
+		afterUpdates: "Jalan C No. 2",
+	} {
+		version, err := service.AddressAsOf(ctx, address.ID, at)
+		if assert.Nil(t, err) {
+			assert.Equal(t, expected, version.Address)
+		}
+	}
+
+	// riwayat tetap ada setelah alamat dihapus
+	assert.Nil(t, db.WithContext(ctx).Delete(&address).Error)
+	afterDelete := tick()
+	_, err = service.AddressAsOf(ctx, address.ID, afterDelete)
+	assert.ErrorIs(t, err, ErrAddressNotFound)
+	version, err := service.AddressAsOf(ctx, address.ID, afterSave)
+	assert.Nil(t, err)
+	assert.Equal(t, "Jalan B No. 2", version.Address)
+}
+
+func TestAddressHistoryBatch(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := context.Background()
+
+	// seperti TestUserAndAddresses, Save dengan association yang dijalankan dua kali
+	user := User{ID: "2", Password: "rahasia", Name: Name{FirstName: "User 50"}, Addresses: []Address{
+		{UserId: "2", Address: "Jalan A"},
+		{UserId: "2", Address: "Jalan B"},
+	}}
+	assert.Nil(t, db.Save(&user).Error)
+	assert.Nil(t, db.Save(&user).Error)
+
+	for _, address := range user.Addresses {
+		history, err := service.AddressHistory(ctx, address.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(history))
+	}
+}
+
+func TestAddressVersionsMigration(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	done, err := migrator.Rollback(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(16), done[0].Version)
+	assert.False(t, db.Migrator().HasTable(&AddressVersion{}))
+	now := time.Now().UTC()
+	err = db.Table("addresses").Create(map[string]interface{}{
+		"user_id": "1", "address": "Jalan A", "street": "Jalan A", "country": "ID", "created_at": now, "updated_at": now,
+	}).Error
+	assert.Nil(t, err)
+
+	_, err = migrator.Apply(ctx)
+	assert.Nil(t, err)
+
+	var address Address
+	assert.Nil(t, db.Take(&address, "user_id = ?", "1").Error)
+	version, err := NewAddressService(db).AddressAsOf(ctx, address.ID, now.Add(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, "Jalan A", version.Fields.Street)
+}
@@ -0,0 +1,146 @@
+package belajargorm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// singkatan yang umum di alamat Indonesia, ditulis huruf kecil tanpa titik
+var addressAbbreviations = map[string]string{
+	"jl":      "jalan",
+	"jln":     "jalan",
+	"gg":      "gang",
+	"nomor":   "no",
+	"nmr":     "no",
+	"kel":     "kelurahan",
+	"kec":     "kecamatan",
+	"kab":     "kabupaten",
+	"prov":    "provinsi",
+	"komp":    "kompleks",
+	"komplek": "kompleks",
+	"perum":   "perumahan",
+	"blk":     "blok",
+	"jend":    "jenderal",
+}
+
+// NormalizeAddress menyeragamkan penulisan alamat agar alamat yang sama bisa dibandingkan
+// huruf kecil, tanda baca dan spasi berlebih dibuang, singkatan seperti "Jl." menjadi "jalan"
+// dan angka tanpa nol di depan, contoh
+//
+//	"JL. Sudirman  No.12 RT 003/RW 005" => "jalan sudirman no 12 rt 3/rw 5"
+func NormalizeAddress(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '/' || r == '-':
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, text)
+
+	tokens := strings.Fields(text)
+	for i, token := range tokens {
+		if expanded, ok := addressAbbreviations[token]; ok {
+			tokens[i] = expanded
+			continue
+		}
+		parts := strings.Split(token, "/")
+		for j, part := range parts {
+			parts[j] = trimLeadingZeros(part)
+		}
+		tokens[i] = strings.Join(parts, "/")
+	}
+	return strings.Join(tokens, " ")
+}
+
+func trimLeadingZeros(s string) string {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return s
+	}
+	if trimmed := strings.TrimLeft(s, "0"); trimmed != "" {
+		return trimmed
+	}
+	return "0"
+}
+
+// normalized mengembalikan salinan f dengan setiap bagian dinormalisasi oleh NormalizeAddress
+func (f AddressFields) normalized() AddressFields {
+	return AddressFields{
+		Street:     NormalizeAddress(f.Street),
+		Number:     NormalizeAddress(f.Number),
+		RT:         trimLeadingZeros(f.RT),
+		RW:         trimLeadingZeros(f.RW),
+		Kelurahan:  NormalizeAddress(f.Kelurahan),
+		Kecamatan:  NormalizeAddress(f.Kecamatan),
+		City:       NormalizeAddress(f.City),
+		Province:   NormalizeAddress(f.Province),
+		PostalCode: strings.TrimSpace(f.PostalCode),
+		Country:    strings.ToUpper(strings.TrimSpace(f.Country)),
+	}
+}
+
+// Fingerprint adalah sha256 (hex) dari bagian alamat yang sudah dinormalisasi
+// dua alamat dengan Fingerprint yang sama dianggap alamat yang sama
+func (f AddressFields) Fingerprint() string {
+	n := f.normalized()
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		n.Street, n.Number, n.RT, n.RW, n.Kelurahan, n.Kecamatan, n.City, n.Province, n.PostalCode, n.Country,
+	}, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SimilarTo bernilai true jika f dan other kemungkinan alamat yang sama (near-duplicate)
+// jalan harus sama setelah dinormalisasi, bagian lain boleh kosong di salah satu sisi
+// misalnya "Jl. Sudirman No. 12" dan "Jalan Sudirman no 12, Jakarta Selatan 12930"
+func (f AddressFields) SimilarTo(other AddressFields) bool {
+	a, b := f.normalized(), other.normalized()
+	if a.Street == "" || a.Street != b.Street {
+		return false
+	}
+	for _, pair := range [][2]string{
+		{a.Number, b.Number}, {a.RT, b.RT}, {a.RW, b.RW}, {a.Kelurahan, b.Kelurahan}, {a.Kecamatan, b.Kecamatan},
+		{a.City, b.City}, {a.Province, b.Province}, {a.PostalCode, b.PostalCode}, {a.Country, b.Country},
+	} {
+		if pair[0] != "" && pair[1] != "" && pair[0] != pair[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeFrom mengisi bagian f yang kosong dengan bagian dari other
+func (f AddressFields) mergeFrom(other AddressFields) AddressFields {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&f.Street, other.Street)
+	fill(&f.Number, other.Number)
+	fill(&f.RT, other.RT)
+	fill(&f.RW, other.RW)
+	fill(&f.Kelurahan, other.Kelurahan)
+	fill(&f.Kecamatan, other.Kecamatan)
+	fill(&f.City, other.City)
+	fill(&f.Province, other.Province)
+	fill(&f.PostalCode, other.PostalCode)
+	fill(&f.Country, other.Country)
+	return f
+}
+
+// columns mengembalikan nama kolom dan nilai setiap bagian alamat, untuk Updates dengan map
+func (f AddressFields) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"street":      f.Street,
+		"number":      f.Number,
+		"rt":          f.RT,
+		"rw":          f.RW,
+		"kelurahan":   f.Kelurahan,
+		"kecamatan":   f.Kecamatan,
+		"city":        f.City,
+		"province":    f.Province,
+		"postal_code": f.PostalCode,
+		"country":     f.Country,
+	}
+}
@@ -0,0 +1,42 @@
+package belajargorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"Jalan A":                          "jalan a",
+		"  JL.  Sudirman   No.12 ":         "jalan sudirman no 12",
+		"Jln Sudirman nomor 012":           "jalan sudirman no 12",
+		"Gg. Mawar RT 003/RW 005":          "gang mawar rt 3/rw 5",
+		"Kel. Karet, Kec. Setiabudi":       "kelurahan karet kecamatan setiabudi",
+		"Komp. Griya Asri Blk. C-2 no. 00": "kompleks griya asri blok c-2 no 0",
+	}
+	for text, expected := range cases {
+		assert.Equal(t, expected, NormalizeAddress(text), text)
+	}
+}
+
+func TestAddressFingerprint(t *testing.T) {
+	t.Parallel()
+
+	a := ParseAddress("Jl. Sudirman No. 12 RT 03/RW 05, Jakarta Selatan").AddressFields
+	b := ParseAddress("JALAN SUDIRMAN no 12 rt 3 rw 5, jakarta selatan").AddressFields
+	assert.Equal(t, a.Fingerprint(), b.Fingerprint())
+	assert.Equal(t, 64, len(a.Fingerprint()))
+
+	c := ParseAddress("Jalan Sudirman No. 14, Jakarta Selatan").AddressFields
+	assert.NotEqual(t, a.Fingerprint(), c.Fingerprint())
+
+	// bagian yang kosong di salah satu alamat tidak membuat alamat berbeda
+	d := ParseAddress("Jalan Sudirman No. 12, Kota Jakarta Selatan, DKI Jakarta 12930").AddressFields
+	assert.NotEqual(t, a.Fingerprint(), d.Fingerprint())
+	assert.True(t, a.SimilarTo(d))
+	assert.False(t, a.SimilarTo(c))
+	assert.False(t, AddressFields{City: "Bandung"}.SimilarTo(AddressFields{City: "Bandung"}))
+}
@@ -0,0 +1,287 @@
+package belajargorm
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AddressFields adalah bagian-bagian alamat Indonesia
+// RT dan RW ditulis tiga digit seperti "003", Country memakai kode ISO 3166-1 alpha-2
+type AddressFields struct {
+	Street     string `gorm:"column:street;size:100"`
+	Number     string `gorm:"column:number;size:20"`
+	RT         string `gorm:"column:rt;size:3"`
+	RW         string `gorm:"column:rw;size:3"`
+	Kelurahan  string `gorm:"column:kelurahan;size:100"`
+	Kecamatan  string `gorm:"column:kecamatan;size:100"`
+	City       string `gorm:"column:city;size:100"`
+	Province   string `gorm:"column:province;size:100"`
+	PostalCode string `gorm:"column:postal_code;size:10"`
+	Country    string `gorm:"column:country;size:2;not null;default:'ID'"`
+}
+
+// IsZero bernilai true jika belum ada bagian alamat yang terisi, Country tidak dihitung
+func (f AddressFields) IsZero() bool {
+	f.Country = ""
+	return f == AddressFields{}
+}
+
+// String menyusun kembali alamat dalam format yang bisa dibaca ParseAddress, contoh
+//
+//	Jl. Sudirman No. 12 RT 003/RW 005, Kel. Karet Semanggi, Kec. Setiabudi, Jakarta Selatan, DKI Jakarta 12930
+func (f AddressFields) String() string {
+	street := f.Street
+	if f.Number != "" {
+		street += " No. " + f.Number
+	}
+	if f.RT != "" || f.RW != "" {
+		street += fmt.Sprintf(" RT %s/RW %s", f.RT, f.RW)
+	}
+	var parts []string
+	for _, part := range []string{
+		strings.TrimSpace(street),
+		prefixed("Kel. ", f.Kelurahan),
+		prefixed("Kec. ", f.Kecamatan),
+		f.City,
+		strings.TrimSpace(f.Province + " " + f.PostalCode),
+	} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func prefixed(prefix, value string) string {
+	if value == "" {
+		return ""
+	}
+	return prefix + value
+}
+
+// ParsedAddress adalah hasil ParseAddress
+// Confidence antara 0 dan 1, bagian yang ditebak dari urutan (tanpa penanda seperti "Kec.") dihitung setengah
+// dan bagian yang tidak dikenali mengurangi nilainya
+type ParsedAddress struct {
+	AddressFields
+	Confidence float64
+}
+
+var (
+	postalCodePattern = regexp.MustCompile(`\b\d{5}\b`)
+	rtRwPattern       = regexp.MustCompile(`(?i)\bRT\s*\.?\s*/\s*RW\s*\.?\s*:?\s*(\d{1,3})\s*/\s*(\d{1,3})\b|\bRT\s*\.?\s*:?\s*(\d{1,3})\s*(?:/\s*(?:RW\s*\.?\s*:?\s*)?|\s+RW\s*\.?\s*:?\s*)(\d{1,3})\b`)
+	rtPattern         = regexp.MustCompile(`(?i)\bRT\s*\.?\s*:?\s*(\d{1,3})\b`)
+	rwPattern         = regexp.MustCompile(`(?i)\bRW\s*\.?\s*:?\s*(\d{1,3})\b`)
+	numberPattern     = regexp.MustCompile(`(?i)\b(?:No|Nomor|Nmr)\s*\.?\s*:?\s*(\d+[A-Za-z]?(?:[/-]\w+)?)`)
+	spacePattern      = regexp.MustCompile(`\s+`)
+)
+
+var (
+	streetPrefixes    = []string{"jl.", "jl ", "jln", "jalan", "gg.", "gg ", "gang", "komp", "komplek", "kompleks", "perum", "perumahan", "blok"}
+	kelurahanPrefixes = []string{"kelurahan", "kel.", "kel ", "desa", "ds."}
+	kecamatanPrefixes = []string{"kecamatan", "kec.", "kec "}
+	cityPrefixes      = []string{"kota ", "kabupaten", "kab.", "kab "}
+	provincePrefixes  = []string{"provinsi", "prov.", "prov "}
+)
+
+// nama provinsi dan singkatan yang umum, kunci ditulis huruf kecil tanpa titik
+var provinces = map[string]string{}
+
+func init() {
+	for _, name := range []string{
+		"Aceh", "Sumatera Utara", "Sumatera Barat", "Riau", "Kepulauan Riau", "Jambi", "Sumatera Selatan",
+		"Kepulauan Bangka Belitung", "Bengkulu", "Lampung", "DKI Jakarta", "Jawa Barat", "Banten", "Jawa Tengah",
+		"DI Yogyakarta", "Jawa Timur", "Bali", "Nusa Tenggara Barat", "Nusa Tenggara Timur", "Kalimantan Barat",
+		"Kalimantan Tengah", "Kalimantan Selatan", "Kalimantan Timur", "Kalimantan Utara", "Sulawesi Utara",
+		"Gorontalo", "Sulawesi Tengah", "Sulawesi Barat", "Sulawesi Selatan", "Sulawesi Tenggara", "Maluku",
+		"Maluku Utara", "Papua", "Papua Barat", "Papua Barat Daya", "Papua Selatan", "Papua Tengah", "Papua Pegunungan",
+	} {
+		provinces[provinceKey(name)] = name
+	}
+	for alias, name := range map[string]string{
+		"jakarta": "DKI Jakarta", "daerah khusus ibukota jakarta": "DKI Jakarta",
+		"yogyakarta": "DI Yogyakarta", "diy": "DI Yogyakarta", "daerah istimewa yogyakarta": "DI Yogyakarta",
+		"jabar": "Jawa Barat", "jateng": "Jawa Tengah", "jatim": "Jawa Timur",
+		"sumut": "Sumatera Utara", "sumbar": "Sumatera Barat", "sumsel": "Sumatera Selatan",
+		"kepri": "Kepulauan Riau", "babel": "Kepulauan Bangka Belitung", "bangka belitung": "Kepulauan Bangka Belitung",
+		"ntb": "Nusa Tenggara Barat", "ntt": "Nusa Tenggara Timur",
+		"kalbar": "Kalimantan Barat", "kalteng": "Kalimantan Tengah", "kalsel": "Kalimantan Selatan",
+		"kaltim": "Kalimantan Timur", "kaltara": "Kalimantan Utara",
+		"sulut": "Sulawesi Utara", "sulteng": "Sulawesi Tengah", "sulbar": "Sulawesi Barat",
+		"sulsel": "Sulawesi Selatan", "sultra": "Sulawesi Tenggara", "malut": "Maluku Utara",
+	} {
+		provinces[alias] = name
+	}
+}
+
+func provinceKey(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, ".", ""))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// bobot setiap bagian alamat untuk Confidence, jumlahnya 1
+const (
+	weightStreet     = 0.30
+	weightNumber     = 0.10
+	weightRTRW       = 0.10
+	weightKelurahan  = 0.10
+	weightKecamatan  = 0.10
+	weightCity       = 0.15
+	weightProvince   = 0.10
+	weightPostalCode = 0.05
+	penaltyUnknown   = 0.10
+)
+
+// ParseAddress memecah alamat bebas seperti
+//
+//	Jl. Sudirman No. 12 RT 03/RW 05, Kel. Karet Semanggi, Kec. Setiabudi, Jakarta Selatan, DKI Jakarta 12930
+//
+// menjadi AddressFields beserta Confidence
+// bagian dipisah dengan koma, kode pos (5 digit), RT/RW dan nomor dikenali di mana saja,
+// kelurahan, kecamatan, kota dan provinsi dikenali dari penandanya (Kel., Kec., Kota, Kab., Prov.)
+// atau dari daftar provinsi, sisanya ditebak dari urutan: jalan, kelurahan, kecamatan, kota
+func ParseAddress(text string) ParsedAddress {
+	text = spacePattern.ReplaceAllString(strings.TrimSpace(text), " ")
+	parsed := ParsedAddress{AddressFields: AddressFields{Country: "ID"}}
+	if text == "" {
+		return parsed
+	}
+	score := 0.0
+
+	if codes := postalCodePattern.FindAllStringIndex(text, -1); len(codes) > 0 {
+		last := codes[len(codes)-1]
+		parsed.PostalCode = text[last[0]:last[1]]
+		text = text[:last[0]] + text[last[1]:]
+		score += weightPostalCode
+	}
+
+	if m := rtRwPattern.FindStringSubmatchIndex(text); m != nil {
+		rt, rw := 2, 4
+		if m[rt] < 0 {
+			rt, rw = 6, 8
+		}
+		parsed.RT, parsed.RW = padRTRW(text[m[rt]:m[rt+1]]), padRTRW(text[m[rw]:m[rw+1]])
+		text = text[:m[0]] + text[m[1]:]
+	} else {
+		if m := rtPattern.FindStringSubmatchIndex(text); m != nil {
+			parsed.RT = padRTRW(text[m[2]:m[3]])
+			text = text[:m[0]] + text[m[1]:]
+		}
+		if m := rwPattern.FindStringSubmatchIndex(text); m != nil {
+			parsed.RW = padRTRW(text[m[2]:m[3]])
+			text = text[:m[0]] + text[m[1]:]
+		}
+	}
+	if parsed.RT != "" && parsed.RW != "" {
+		score += weightRTRW
+	} else if parsed.RT != "" || parsed.RW != "" {
+		score += weightRTRW / 2
+	}
+
+	if m := numberPattern.FindStringSubmatchIndex(text); m != nil {
+		parsed.Number = text[m[2]:m[3]]
+		text = text[:m[0]] + text[m[1]:]
+		score += weightNumber
+	}
+
+	var unlabeled []string
+	for i, segment := range strings.Split(text, ",") {
+		segment = strings.Trim(strings.TrimSpace(segment), " .,/-")
+		if segment == "" {
+			continue
+		}
+		lower := strings.ToLower(segment)
+
+		if value, ok := cutPrefix(segment, lower, kelurahanPrefixes); ok && parsed.Kelurahan == "" {
+			parsed.Kelurahan = value
+			score += weightKelurahan
+		} else if value, ok := cutPrefix(segment, lower, kecamatanPrefixes); ok && parsed.Kecamatan == "" {
+			parsed.Kecamatan = value
+			score += weightKecamatan
+		} else if value, ok := cutPrefix(segment, lower, provincePrefixes); ok && parsed.Province == "" {
+			parsed.Province = value
+			if name, ok := provinces[provinceKey(value)]; ok {
+				parsed.Province = name
+			}
+			score += weightProvince
+		} else if parsed.City == "" && hasAnyPrefix(lower, cityPrefixes) {
+			parsed.City = cityName(segment, lower)
+			score += weightCity
+		} else if name, ok := provinces[provinceKey(segment)]; ok && i > 0 && parsed.Province == "" {
+			parsed.Province = name
+			score += weightProvince
+		} else if lower == "indonesia" {
+			parsed.Country = "ID"
+		} else if parsed.Street == "" && (i == 0 || hasAnyPrefix(lower, streetPrefixes)) {
+			parsed.Street = segment
+			score += weightStreet
+		} else {
+			unlabeled = append(unlabeled, segment)
+		}
+	}
+
+	// bagian tanpa penanda diisi dari belakang: kota, kecamatan, lalu kelurahan
+	for i := len(unlabeled) - 1; i >= 0; i-- {
+		switch {
+		case parsed.City == "":
+			parsed.City = unlabeled[i]
+			score += weightCity / 2
+		case parsed.Kecamatan == "":
+			parsed.Kecamatan = unlabeled[i]
+			score += weightKecamatan / 2
+		case parsed.Kelurahan == "":
+			parsed.Kelurahan = unlabeled[i]
+			score += weightKelurahan / 2
+		default:
+			score -= penaltyUnknown
+		}
+	}
+
+	parsed.Confidence = math.Round(math.Max(0, math.Min(1, score))*100) / 100
+	return parsed
+}
+
+func padRTRW(s string) string {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return s
+	}
+	return fmt.Sprintf("%03d", n)
+}
+
+func hasAnyPrefix(lower string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cutPrefix membuang penanda seperti "Kec." dari segment, lower adalah segment dalam huruf kecil
+func cutPrefix(segment, lower string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			value := strings.Trim(segment[len(prefix):], " .:")
+			return value, value != ""
+		}
+	}
+	return "", false
+}
+
+// cityName membuang awalan "Kota" dan menyeragamkan "Kab." menjadi "Kabupaten"
+// karena kota dan kabupaten dengan nama yang sama adalah daerah yang berbeda
+func cityName(segment, lower string) string {
+	if strings.HasPrefix(lower, "kota ") {
+		return strings.TrimSpace(segment[len("kota "):])
+	}
+	for _, prefix := range []string{"kabupaten", "kab.", "kab "} {
+		if strings.HasPrefix(lower, prefix) {
+			return "Kabupaten " + strings.Trim(segment[len(prefix):], " .:")
+		}
+	}
+	return segment
+}
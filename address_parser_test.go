@@ -0,0 +1,124 @@
+package belajargorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		text       string
+		fields     AddressFields
+		confidence float64
+	}{
+		{
+			text: "Jl. Sudirman No. 12 RT 03/RW 05, Kel. Karet Semanggi, Kec. Setiabudi, Kota Jakarta Selatan, DKI Jakarta 12930",
+			fields: AddressFields{
+				Street: "Jl. Sudirman", Number: "12", RT: "003", RW: "005",
+				Kelurahan: "Karet Semanggi", Kecamatan: "Setiabudi", City: "Jakarta Selatan",
+				Province: "DKI Jakarta", PostalCode: "12930", Country: "ID",
+			},
+			confidence: 1,
+		},
+		{
+			text: "Jalan Merdeka Nomor 5A RT.001 RW.002, Sukajadi, Bandung, Jabar",
+			fields: AddressFields{
+				Street: "Jalan Merdeka", Number: "5A", RT: "001", RW: "002",
+				Kecamatan: "Sukajadi", City: "Bandung", Province: "Jawa Barat", Country: "ID",
+			},
+			confidence: 0.73,
+		},
+		{
+			text:       "Gg. Mawar no 3, RT/RW 004/011, Desa Cibiru, Kab. Bandung, Prov. Jawa Barat, Indonesia 40615",
+			fields:     AddressFields{Street: "Gg. Mawar", Number: "3", RT: "004", RW: "011", Kelurahan: "Cibiru", City: "Kabupaten Bandung", Province: "Jawa Barat", PostalCode: "40615", Country: "ID"},
+			confidence: 0.9,
+		},
+		{
+			text:       "Jalan A",
+			fields:     AddressFields{Street: "Jalan A", Country: "ID"},
+			confidence: 0.3,
+		},
+		{
+			text:       "",
+			fields:     AddressFields{Country: "ID"},
+			confidence: 0,
+		},
+	}
+	for _, c := range cases {
+		parsed := ParseAddress(c.text)
+		assert.Equal(t, c.fields, parsed.AddressFields, c.text)
+		assert.Equal(t, c.confidence, parsed.Confidence, c.text)
+	}
+
+	// String bisa dibaca kembali oleh ParseAddress
+	full := cases[0].fields
+	assert.Equal(t, "Jl. Sudirman No. 12 RT 003/RW 005, Kel. Karet Semanggi, Kec. Setiabudi, Jakarta Selatan, DKI Jakarta 12930", full.String())
+	reparsed := ParseAddress(full.String())
+	assert.Equal(t, full, reparsed.AddressFields)
+}
+
+func TestCreateStructuredAddress(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	parsed := Address{UserId: "1", Address: "Jl. Braga No. 8, Kec. Sumur Bandung, Kota Bandung"}
+	assert.Nil(t, db.Create(&parsed).Error)
+	assert.Equal(t, "Jl. Braga", parsed.Fields.Street)
+	assert.Equal(t, "Bandung", parsed.Fields.City)
+	assert.Equal(t, 0.65, parsed.ParseConfidence)
+
+	structured := Address{UserId: "1", Fields: AddressFields{Street: "Jl. Asia Afrika", Number: "1", City: "Bandung"}}
+	assert.Nil(t, db.Create(&structured).Error)
+	assert.Equal(t, "Jl. Asia Afrika No. 1, Bandung", structured.Address)
+	assert.Equal(t, float64(1), structured.ParseConfidence)
+
+	var saved Address
+	assert.Nil(t, db.Take(&saved, "id = ?", structured.ID).Error)
+	assert.Equal(t, "ID", saved.Fields.Country)
+	assert.Equal(t, "1", saved.Fields.Number)
+}
+
+func TestStructuredAddressMigration(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	for {
+		done, err := migrator.Rollback(ctx, 1)
+		assert.Nil(t, err)
+		if len(done) == 0 || done[0].Version == 12 {
+			break
+		}
+	}
+	assert.False(t, db.Migrator().HasColumn(&Address{}, "street"))
+
+	now := time.Now()
+	for _, text := range []string{"Jalan A", "Jl. Gatot Subroto No. 7, Kec. Setiabudi, Jakarta Selatan 12950"} {
+		err := db.Table("addresses").Create(map[string]interface{}{
+			"user_id": "1", "address": text, "created_at": now, "updated_at": now,
+		}).Error
+		assert.Nil(t, err)
+	}
+
+	_, err := migrator.Apply(ctx)
+	assert.Nil(t, err)
+
+	var addresses []Address
+	assert.Nil(t, db.Order("id").Find(&addresses).Error)
+	assert.Equal(t, 2, len(addresses))
+	assert.Equal(t, "Jalan A", addresses[0].Address)
+	assert.Equal(t, "Jalan A", addresses[0].Fields.Street)
+	assert.Equal(t, 0.3, addresses[0].ParseConfidence)
+	assert.Equal(t, "Jl. Gatot Subroto No. 7, Kec. Setiabudi, Jakarta Selatan 12950", addresses[1].Address)
+	assert.Equal(t, "7", addresses[1].Fields.Number)
+	assert.Equal(t, "Setiabudi", addresses[1].Fields.Kecamatan)
+	assert.Equal(t, "Jakarta Selatan", addresses[1].Fields.City)
+	assert.Equal(t, "12950", addresses[1].Fields.PostalCode)
+	assert.Equal(t, "ID", addresses[1].Fields.Country)
+}
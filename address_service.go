@@ -0,0 +1,197 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddressService berisi operasi Address yang melibatkan lebih dari satu baris
+type AddressService struct {
+	db *gorm.DB
+
+	// MaxAttempts adalah jumlah percobaan transaction jika terjadi deadlock atau serialization failure
+	MaxAttempts int
+}
+
+func NewAddressService(db *gorm.DB) *AddressService {
+	return &AddressService{db: db, MaxAttempts: defaultMaxAttempts}
+}
+
+func (s *AddressService) transaction(ctx context.Context, fc func(tx *gorm.DB) error) error {
+	return transaction(ctx, s.db, s.MaxAttempts, fc)
+}
+
+// PrimaryAddress mengembalikan alamat primary user untuk addressType
+// ErrAddressNotFound jika user belum punya alamat dengan type tersebut
+func (s *AddressService) PrimaryAddress(ctx context.Context, userID string, addressType AddressType) (*Address, error) {
+	var address Address
+	err := s.db.WithContext(ctx).
+		Take(&address, "user_id = ? AND type = ? AND is_primary = ?", userID, addressType, true).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: user %s has no primary %s address", ErrAddressNotFound, userID, addressType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// SetPrimaryAddress menjadikan addressID alamat primary milik userID untuk type alamat tersebut
+// alamat primary sebelumnya dengan type yang sama dilepas di transaction yang sama,
+// perubahannya dicatat oleh AuditPlugin
+func (s *AddressService) SetPrimaryAddress(ctx context.Context, userID string, addressID int64) error {
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		var target Address
+		err := tx.Take(&target, "id = ? AND user_id = ?", addressID, userID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %d of user %s", ErrAddressNotFound, addressID, userID)
+		}
+		if err != nil {
+			return err
+		}
+		return setPrimary(tx, target)
+	})
+}
+
+// setPrimary menjadikan target alamat primary, dipanggil di dalam transaction
+func setPrimary(tx *gorm.DB, target Address) error {
+	// semua alamat dengan type yang sama dikunci agar pergantian yang bersamaan tidak melanggar idx_addresses_primary
+	var addresses []Address
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND type = ?", target.UserId, target.Type).Order("id").Find(&addresses).Error
+	if err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		if address.ID == target.ID && address.IsPrimary {
+			return nil
+		}
+	}
+
+	// primary lama dilepas lebih dulu, unique index diperiksa per statement
+	err = tx.Model(&Address{}).Where("user_id = ? AND type = ? AND is_primary = ?", target.UserId, target.Type, true).
+		Update("is_primary", false).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&Address{}).Where("id = ?", target.ID).Update("is_primary", true).Error
+}
+
+// DuplicatePolicy menentukan apa yang dilakukan AddAddress jika user sudah punya alamat yang sama
+type DuplicatePolicy int
+
+const (
+	// RejectDuplicate menolak alamat baru dengan *DuplicateAddressError
+	RejectDuplicate DuplicatePolicy = iota
+	// MergeDuplicate melengkapi alamat yang sudah ada dengan bagian alamat baru yang belum terisi
+	MergeDuplicate
+)
+
+// DuplicateAddressError dikembalikan AddAddress jika alamat yang sama sudah tersimpan
+// errors.Is(err, ErrDuplicateAddress) bernilai true
+type DuplicateAddressError struct {
+	UserID     string
+	ExistingID int64
+}
+
+func (e *DuplicateAddressError) Error() string {
+	return fmt.Sprintf("belajargorm: user %s already has this address as address %d", e.UserID, e.ExistingID)
+}
+
+func (e *DuplicateAddressError) Is(target error) bool {
+	return target == ErrDuplicateAddress
+}
+
+// AddAddress menyimpan alamat baru milik address.UserId
+// alamat dianggap sama jika Type sama dan Fingerprint sama atau AddressFields.SimilarTo bernilai true,
+// misalnya "Jl. Sudirman No. 12" dan "JALAN SUDIRMAN NO 12, Jakarta"
+//
+// dengan RejectDuplicate alamat yang sama ditolak dengan *DuplicateAddressError,
+// dengan MergeDuplicate bagian alamat lama yang kosong dilengkapi dari alamat baru
+// lalu *address diganti dengan alamat lama yang sudah digabung
+// baris user dikunci selama pengecekan agar dua AddAddress yang bersamaan tidak sama-sama lolos
+func (s *AddressService) AddAddress(ctx context.Context, address *Address, policy DuplicatePolicy) error {
+	if address.Type == "" {
+		address.Type = AddressHome
+	}
+	if !validAddressType(address.Type) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddressType, address.Type)
+	}
+	address.complete()
+
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		var users []string
+		err := tx.Model(&User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", address.UserId).Pluck("id", &users).Error
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, address.UserId)
+		}
+
+		var addresses []Address
+		err = tx.Where("user_id = ? AND type = ?", address.UserId, address.Type).Order("id").Find(&addresses).Error
+		if err != nil {
+			return err
+		}
+		existing := findDuplicate(addresses, address)
+		if existing == nil {
+			return tx.Create(address).Error
+		}
+		if policy != MergeDuplicate {
+			return &DuplicateAddressError{UserID: address.UserId, ExistingID: existing.ID}
+		}
+
+		if err := mergeAddress(tx, existing, address.Fields); err != nil {
+			return err
+		}
+		if address.IsPrimary && !existing.IsPrimary {
+			if err := setPrimary(tx, *existing); err != nil {
+				return err
+			}
+		}
+		*address = Address{}
+		return tx.Take(address, "id = ?", existing.ID).Error
+	})
+}
+
+// findDuplicate mengembalikan alamat di addresses yang sama dengan address
+// Fingerprint yang sama didahulukan sebelum AddressFields.SimilarTo
+func findDuplicate(addresses []Address, address *Address) *Address {
+	for i := range addresses {
+		if addresses[i].ID != address.ID && addresses[i].Fingerprint == address.Fingerprint {
+			return &addresses[i]
+		}
+	}
+	for i := range addresses {
+		if addresses[i].ID != address.ID && addresses[i].Fields.SimilarTo(address.Fields) {
+			return &addresses[i]
+		}
+	}
+	return nil
+}
+
+// mergeAddress melengkapi bagian existing yang kosong dengan fields dan menghitung ulang Fingerprint
+// kolom address tetap berisi teks asli existing, hanya kolom yang berubah yang di-update, perubahannya dicatat oleh AuditPlugin
+func mergeAddress(tx *gorm.DB, existing *Address, fields AddressFields) error {
+	merged := existing.Fields.mergeFrom(fields)
+	updates := map[string]interface{}{}
+	current := existing.Fields.columns()
+	for column, value := range merged.columns() {
+		if current[column] != value {
+			updates[column] = value
+		}
+	}
+	if fingerprint := merged.Fingerprint(); fingerprint != existing.Fingerprint {
+		updates["fingerprint"] = fingerprint
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return tx.Model(&Address{}).Where("id = ?", existing.ID).Updates(updates).Error
+}
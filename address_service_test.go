@@ -0,0 +1,148 @@
+package belajargorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrimaryAddress(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := WithActor(context.Background(), "1")
+
+	addresses := []Address{
+		{UserId: "1", Address: "Jalan A"},
+		{UserId: "1", Address: "Jalan B"},
+		{UserId: "1", Type: AddressShipping, Address: "Jalan C"},
+	}
+	assert.Nil(t, db.Create(&addresses).Error)
+	assert.Equal(t, AddressHome, addresses[0].Type)
+	assert.True(t, addresses[0].IsPrimary)
+	assert.False(t, addresses[1].IsPrimary)
+	assert.True(t, addresses[2].IsPrimary)
+
+	home, err := service.PrimaryAddress(ctx, "1", AddressHome)
+	assert.Nil(t, err)
+	assert.Equal(t, addresses[0].ID, home.ID)
+
+	assert.Nil(t, service.SetPrimaryAddress(ctx, "1", addresses[1].ID))
+	home, err = service.PrimaryAddress(ctx, "1", AddressHome)
+	assert.Nil(t, err)
+	assert.Equal(t, "Jalan B", home.Address)
+
+	// alamat shipping tidak terpengaruh
+	shipping, err := service.PrimaryAddress(ctx, "1", AddressShipping)
+	assert.Nil(t, err)
+	assert.Equal(t, "Jalan C", shipping.Address)
+
+	// pergantian primary dicatat oleh AuditPlugin
+	var logs []UserLog
+	assert.Nil(t, db.Where("entity = ? AND action = ?", "addresses", AuditUpdate).Order("id").Find(&logs).Error)
+	assert.Equal(t, 2, len(logs))
+	assert.Equal(t, "1", logs[0].ActorId)
+
+	// idx_addresses_primary menolak primary kedua
+	assert.NotNil(t, db.Create(&Address{UserId: "1", Address: "Jalan D", IsPrimary: true}).Error)
+}
+
+func TestPrimaryAddressErrors(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := context.Background()
+
+	_, err := service.PrimaryAddress(ctx, "1", AddressBilling)
+	assert.ErrorIs(t, err, ErrAddressNotFound)
+
+	address := Address{UserId: "1", Address: "Jalan A"}
+	assert.Nil(t, db.Create(&address).Error)
+	assert.ErrorIs(t, service.SetPrimaryAddress(ctx, "2", address.ID), ErrAddressNotFound)
+	assert.Nil(t, service.SetPrimaryAddress(ctx, "1", address.ID))
+
+	assert.ErrorIs(t, db.Create(&Address{UserId: "1", Type: "office", Address: "Jalan B"}).Error, ErrInvalidAddressType)
+}
+
+func TestAddressRolesMigration(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	for {
+		done, err := migrator.Rollback(ctx, 1)
+		assert.Nil(t, err)
+		if len(done) == 0 || done[0].Version == 13 {
+			break
+		}
+	}
+	assert.False(t, db.Migrator().HasColumn(&Address{}, "is_primary"))
+	now := time.Now()
+	for _, row := range [][2]string{{"1", "Jalan A"}, {"1", "Jalan B"}, {"2", "Jalan C"}} {
+		err := db.Table("addresses").Create(map[string]interface{}{
+			"user_id": row[0], "address": row[1], "created_at": now, "updated_at": now,
+		}).Error
+		assert.Nil(t, err)
+	}
+
+	_, err := migrator.Apply(ctx)
+	assert.Nil(t, err)
+
+	var addresses []Address
+	assert.Nil(t, db.Order("id").Find(&addresses).Error)
+	assert.Equal(t, 3, len(addresses))
+	assert.Equal(t, []bool{true, false, true}, []bool{addresses[0].IsPrimary, addresses[1].IsPrimary, addresses[2].IsPrimary})
+	assert.Equal(t, AddressHome, addresses[1].Type)
+}
+
+func TestAddAddress(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := WithActor(context.Background(), "2")
+
+	first := Address{UserId: "2", Address: "Jalan A"}
+	assert.Nil(t, service.AddAddress(ctx, &first, RejectDuplicate))
+	assert.True(t, first.IsPrimary)
+	assert.Equal(t, first.Fields.Fingerprint(), first.Fingerprint)
+
+	// "Jalan A" yang sama tidak bisa ditambahkan lagi, termasuk dengan penulisan berbeda
+	for _, text := range []string{"Jalan A", "JL. a", "jln  A"} {
+		err := service.AddAddress(ctx, &Address{UserId: "2", Address: text}, RejectDuplicate)
+		assert.ErrorIs(t, err, ErrDuplicateAddress, text)
+		var duplicate *DuplicateAddressError
+		if assert.ErrorAs(t, err, &duplicate) {
+			assert.Equal(t, first.ID, duplicate.ExistingID)
+		}
+	}
+
+	// alamat yang sama untuk type lain bukan duplikat
+	billing := Address{UserId: "2", Type: AddressBilling, Address: "Jalan A"}
+	assert.Nil(t, service.AddAddress(ctx, &billing, RejectDuplicate))
+	assert.NotEqual(t, first.ID, billing.ID)
+
+	// alamat yang lebih lengkap digabung ke alamat lama
+	merged := Address{UserId: "2", Address: "Jl. A, Kota Bandung, Jawa Barat 40115"}
+	assert.Nil(t, service.AddAddress(ctx, &merged, MergeDuplicate))
+	assert.Equal(t, first.ID, merged.ID)
+	// teks asli alamat lama tidak diubah, hanya bagian alamatnya yang dilengkapi
+	assert.Equal(t, "Jalan A", merged.Address)
+	assert.Equal(t, "Bandung", merged.Fields.City)
+	assert.Equal(t, "40115", merged.Fields.PostalCode)
+	assert.Equal(t, merged.Fields.Fingerprint(), merged.Fingerprint)
+
+	var count int64
+	assert.Nil(t, db.Model(&Address{}).Where("user_id = ?", "2").Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	// penggabungan dicatat oleh AuditPlugin
+	var logs []UserLog
+	assert.Nil(t, db.Where("entity = ? AND action = ?", "addresses", AuditUpdate).Find(&logs).Error)
+	assert.Equal(t, 1, len(logs))
+
+	assert.ErrorIs(t, service.AddAddress(ctx, &Address{UserId: "404", Address: "Jalan A"}, RejectDuplicate), ErrUserNotFound)
+	assert.ErrorIs(t, service.AddAddress(ctx, &Address{UserId: "2", Type: "office", Address: "Jalan Z"}, RejectDuplicate), ErrInvalidAddressType)
+}
@@ -0,0 +1,366 @@
+package belajargorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuditAction adalah jenis aksi yang dicatat di user_logs
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// tabel yang dicatat oleh AuditPlugin beserta kolom yang menunjukkan pemilik datanya
+var auditedTables = map[string]string{
+	"users":     "id",
+	"wallets":   "user_id",
+	"addresses": "user_id",
+	"todos":     "user_id",
+}
+
+// kolom yang nilainya tidak boleh ditulis ke user_logs
+var auditRedactedColumns = map[string]bool{
+	"password": true,
+}
+
+const auditBeforeKey = "belajargorm:audit_before"
+
+type actorKey struct{}
+
+// WithActor menyimpan id user yang sedang melakukan aksi ke dalam context
+// gunakan bersama db.WithContext(ctx) agar tercatat di user_logs
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFromContext mengambil id user yang disimpan oleh WithActor
+func ActorFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	userID, ok := ctx.Value(actorKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// AuditChange adalah nilai lama dan baru dari satu kolom di UserLog.Changes
+type AuditChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditPlugin mencatat setiap create, update dan delete pada User, Wallet, Address dan Todo ke user_logs
+// log ditulis di transaction yang sama dengan perubahan datanya, sehingga jika log gagal ditulis
+// perubahan data ikut di rollback (kecuali SkipDefaultTransaction aktif dan tidak memakai Transaction)
+//
+//	db.Use(AuditPlugin{})
+type AuditPlugin struct{}
+
+func (AuditPlugin) Name() string {
+	return "belajargorm:audit"
+}
+
+func (p AuditPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:create").Register("belajargorm:audit_after_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("belajargorm:audit_before_update", p.before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("belajargorm:audit_after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("belajargorm:audit_before_delete", p.before); err != nil {
+		return err
+	}
+	return callback.Delete().After("gorm:delete").Register("belajargorm:audit_after_delete", p.afterDelete)
+}
+
+func audited(db *gorm.DB) bool {
+	if db.Error != nil || db.Statement.Schema == nil || len(db.Statement.Schema.PrimaryFields) != 1 {
+		return false
+	}
+	_, ok := auditedTables[db.Statement.Table]
+	return ok
+}
+
+// before menyimpan kondisi data sebelum update/delete
+func (p AuditPlugin) before(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	var conds []clause.Expression
+	if where, ok := db.Statement.Clauses["WHERE"]; ok {
+		conds = append(conds, where.Expression)
+	}
+	if pks := primaryKeys(db); len(pks) > 0 {
+		conds = append(conds, clause.IN{Column: clause.PrimaryColumn, Values: pks})
+	}
+	if len(conds) == 0 {
+		return
+	}
+
+	rows, err := auditSelect(db, db.Statement.Unscoped, conds...)
+	if err != nil {
+		db.AddError(fmt.Errorf("belajargorm: audit: %w", err))
+		return
+	}
+	db.InstanceSet(auditBeforeKey, rows)
+}
+
+func (p AuditPlugin) afterCreate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	pks := primaryKeys(db)
+	if len(pks) == 0 {
+		return
+	}
+	after, err := auditSelect(db, true, clause.IN{Column: clause.PrimaryColumn, Values: pks})
+	if err != nil {
+		db.AddError(fmt.Errorf("belajargorm: audit: %w", err))
+		return
+	}
+	p.write(db, AuditCreate, nil, after)
+}
+
+func (p AuditPlugin) afterUpdate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	before := auditBefore(db)
+	if len(before) == 0 {
+		return
+	}
+	after, err := auditSelect(db, true, clause.IN{Column: clause.PrimaryColumn, Values: rowKeys(db, before)})
+	if err != nil {
+		db.AddError(fmt.Errorf("belajargorm: audit: %w", err))
+		return
+	}
+	p.write(db, AuditUpdate, before, after)
+}
+
+func (p AuditPlugin) afterDelete(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	before := auditBefore(db)
+	if len(before) == 0 {
+		return
+	}
+	p.write(db, AuditDelete, before, nil)
+}
+
+func auditBefore(db *gorm.DB) []map[string]interface{} {
+	v, ok := db.InstanceGet(auditBeforeKey)
+	if !ok {
+		return nil
+	}
+	return v.([]map[string]interface{})
+}
+
+// write menulis satu UserLog untuk setiap baris yang berubah
+func (p AuditPlugin) write(db *gorm.DB, action AuditAction, before, after []map[string]interface{}) {
+	pk := db.Statement.Schema.PrioritizedPrimaryField.DBName
+	beforeByKey := map[string]map[string]interface{}{}
+	for _, row := range before {
+		beforeByKey[fmt.Sprint(row[pk])] = row
+	}
+	afterByKey := map[string]map[string]interface{}{}
+	for _, row := range after {
+		afterByKey[fmt.Sprint(row[pk])] = row
+	}
+
+	keys := make([]string, 0, len(beforeByKey)+len(afterByKey))
+	for key := range beforeByKey {
+		keys = append(keys, key)
+	}
+	for key := range afterByKey {
+		if _, ok := beforeByKey[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	actor, _ := ActorFromContext(db.Statement.Context)
+	ownerColumn := auditedTables[db.Statement.Table]
+
+	var logs []UserLog
+	for _, key := range keys {
+		old, current := beforeByKey[key], afterByKey[key]
+		changes := auditDiff(old, current)
+		if len(changes) == 0 {
+			continue
+		}
+		encoded, err := json.Marshal(changes)
+		if err != nil {
+			db.AddError(fmt.Errorf("belajargorm: audit: %w", err))
+			return
+		}
+
+		owner := current[ownerColumn]
+		if owner == nil {
+			owner = old[ownerColumn]
+		}
+		logs = append(logs, UserLog{
+			UserId:   auditString(owner),
+			ActorId:  actor,
+			Action:   action,
+			Entity:   db.Statement.Table,
+			EntityId: key,
+			Changes:  string(encoded),
+		})
+	}
+	if len(logs) == 0 {
+		return
+	}
+
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&logs).Error
+	if err != nil {
+		db.AddError(fmt.Errorf("belajargorm: audit: %w", err))
+	}
+}
+
+// writeUserLog mencatat aksi yang tidak bisa dicatat otomatis oleh AuditPlugin ke user_logs,
+// misalnya koreksi saldo atau persetujuan operasi, actor diambil dari context tx (WithActor)
+func writeUserLog(tx *gorm.DB, userID string, action AuditAction, entity, entityID string, changes map[string]AuditChange) error {
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	actor, _ := ActorFromContext(tx.Statement.Context)
+	return tx.Session(&gorm.Session{SkipHooks: true}).Create(&UserLog{
+		UserId:   userID,
+		ActorId:  actor,
+		Action:   action,
+		Entity:   entity,
+		EntityId: entityID,
+		Changes:  string(encoded),
+	}).Error
+}
+
+// writeWalletLog mencatat perubahan kolom wallet yang ditulis lewat tx.Table("wallets"), yaitu kolom <-:create
+// seperti balance, status dan is_default, karena statement tanpa Schema dilewati AuditPlugin
+// seperti AuditPlugin, log hanya ditulis jika AuditPlugin dipakai (lihat Config.DisableAudit)
+func writeWalletLog(tx *gorm.DB, walletID, userID string, changes map[string]AuditChange) error {
+	if _, ok := tx.Config.Plugins[AuditPlugin{}.Name()]; !ok {
+		return nil
+	}
+	return writeUserLog(tx, userID, AuditUpdate, "wallets", walletID, changes)
+}
+
+// auditSelect membaca baris yang terkena statement sebagai map, di koneksi/transaction yang sama
+func auditSelect(db *gorm.DB, unscoped bool, conds ...clause.Expression) ([]map[string]interface{}, error) {
+	tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Model(reflect.New(db.Statement.Schema.ModelType).Interface()).
+		Clauses(conds...)
+	if unscoped {
+		tx = tx.Unscoped()
+	}
+	var rows []map[string]interface{}
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// primaryKeys mengambil primary key yang tidak kosong dari model/dest statement
+func primaryKeys(db *gorm.DB) []interface{} {
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return nil
+	}
+	var pks []interface{}
+	ctx := db.Statement.Context
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			elem := reflect.Indirect(rv.Index(i))
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			if v, zero := field.ValueOf(ctx, elem); !zero {
+				pks = append(pks, v)
+			}
+		}
+	case reflect.Struct:
+		if v, zero := field.ValueOf(ctx, rv); !zero {
+			pks = append(pks, v)
+		}
+	}
+	return pks
+}
+
+func rowKeys(db *gorm.DB, rows []map[string]interface{}) []interface{} {
+	pk := db.Statement.Schema.PrioritizedPrimaryField.DBName
+	keys := make([]interface{}, len(rows))
+	for i, row := range rows {
+		keys[i] = row[pk]
+	}
+	return keys
+}
+
+func auditDiff(before, after map[string]interface{}) map[string]AuditChange {
+	changes := map[string]AuditChange{}
+	for column, value := range after {
+		old := before[column]
+		if before != nil && auditEqual(old, value) {
+			continue
+		}
+		changes[column] = auditChangeOf(column, old, value)
+	}
+	if after == nil {
+		for column, old := range before {
+			changes[column] = auditChangeOf(column, old, nil)
+		}
+	}
+	return changes
+}
+
+func auditChangeOf(column string, old, value interface{}) AuditChange {
+	if auditRedactedColumns[column] {
+		change := AuditChange{}
+		if old != nil {
+			change.Old = "[redacted]"
+		}
+		if value != nil {
+			change.New = "[redacted]"
+		}
+		return change
+	}
+	return AuditChange{Old: auditValue(old), New: auditValue(value)}
+}
+
+func auditValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+func auditEqual(a, b interface{}) bool {
+	a, b = auditValue(a), auditValue(b)
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func auditString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(auditValue(v))
+}
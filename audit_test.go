@@ -0,0 +1,127 @@
+package belajargorm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func auditLogs(t *testing.T, db *gorm.DB, entity, entityID string) []UserLog {
+	t.Helper()
+	var logs []UserLog
+	err := db.Where("entity = ? AND entity_id = ?", entity, entityID).Order("id").Find(&logs).Error
+	assert.Nil(t, err)
+	return logs
+}
+
+func auditChanges(t *testing.T, log UserLog) map[string]AuditChange {
+	t.Helper()
+	var changes map[string]AuditChange
+	assert.Nil(t, json.Unmarshal([]byte(log.Changes), &changes))
+	return changes
+}
+
+func TestAuditUser(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := WithActor(context.Background(), "1")
+
+	user := User{ID: "50", Password: "rahasia", Name: Name{FirstName: "User 50"}}
+	err := db.WithContext(ctx).Create(&user).Error
+	assert.Nil(t, err)
+
+	err = db.WithContext(ctx).Model(&User{}).Where("id = ?", "50").Updates(map[string]interface{}{
+		"first_name": "Budi",
+		"password":   "diubah",
+	}).Error
+	assert.Nil(t, err)
+
+	err = db.WithContext(ctx).Delete(&User{}, "id = ?", "50").Error
+	assert.Nil(t, err)
+
+	logs := auditLogs(t, db, "users", "50")
+	assert.Equal(t, 3, len(logs))
+	for _, log := range logs {
+		assert.Equal(t, "50", log.UserId)
+		assert.Equal(t, "1", log.ActorId)
+	}
+
+	assert.Equal(t, AuditCreate, logs[0].Action)
+	changes := auditChanges(t, logs[0])
+	assert.Nil(t, changes["first_name"].Old)
+	assert.Equal(t, "User 50", changes["first_name"].New)
+	assert.Equal(t, "[redacted]", changes["password"].New)
+
+	assert.Equal(t, AuditUpdate, logs[1].Action)
+	changes = auditChanges(t, logs[1])
+	assert.Equal(t, "User 50", changes["first_name"].Old)
+	assert.Equal(t, "Budi", changes["first_name"].New)
+	assert.Equal(t, "[redacted]", changes["password"].Old)
+	assert.NotContains(t, changes, "last_name")
+
+	assert.Equal(t, AuditDelete, logs[2].Action)
+	changes = auditChanges(t, logs[2])
+	assert.Equal(t, "Budi", changes["first_name"].Old)
+	assert.Nil(t, changes["first_name"].New)
+}
+
+func TestAuditSoftDeleteTodo(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	todo := Todo{UserId: "2", Title: "Todo 2"}
+	err := db.Create(&todo).Error
+	assert.Nil(t, err)
+	err = db.Delete(&todo).Error
+	assert.Nil(t, err)
+
+	logs := auditLogs(t, db, "todos", "2")
+	assert.Equal(t, 2, len(logs))
+	assert.Equal(t, AuditCreate, logs[0].Action)
+	assert.Equal(t, AuditDelete, logs[1].Action)
+	assert.Equal(t, "2", logs[1].UserId)
+	assert.Equal(t, "", logs[1].ActorId)
+}
+
+func TestAuditRollback(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&Wallet{ID: "50", UserId: "2", Balance: 1000}).Error; err != nil {
+			return err
+		}
+		return errors.New("batal")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(auditLogs(t, db, "wallets", "50")))
+
+	err = db.Create(&Wallet{ID: "50", UserId: "2", Balance: 1000}).Error
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(auditLogs(t, db, "wallets", "50")))
+}
+
+func TestAuditWalletBalance(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 1000)
+	ctx := WithActor(context.Background(), "9")
+
+	// balance ditulis lewat Table oleh ledger, tetap dicatat di user_logs
+	assert.Nil(t, NewWalletService(db).Transfer(ctx, "2", "3", 300))
+	for id, balance := range map[string][2]float64{"2": {1000, 700}, "3": {1000, 1300}} {
+		logs := auditLogs(t, db, "wallets", id)
+		if assert.Equal(t, 2, len(logs), id) {
+			assert.Equal(t, AuditUpdate, logs[1].Action)
+			assert.Equal(t, id, logs[1].UserId)
+			assert.Equal(t, "9", logs[1].ActorId)
+			changes := auditChanges(t, logs[1])
+			assert.Equal(t, balance[0], changes["balance"].Old)
+			assert.Equal(t, balance[1], changes["balance"].New)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// dedupeaddresses mencari alamat yang sama milik satu user dan melaporkannya
+// dengan -merge, duplikat digabung ke alamat primary atau alamat paling lama lalu dihapus,
+// perubahan dicatat di user_logs
+//
+//	go run ./cmd/dedupeaddresses
+//	go run ./cmd/dedupeaddresses -merge -actor 1
+//
+// koneksi database dibaca dari .env / environment variable, lihat belajargorm.LoadConfig
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	belajargorm "belajar-gorm"
+)
+
+func main() {
+	merge := flag.Bool("merge", false, "gabungkan dan hapus alamat yang duplikat")
+	actor := flag.String("actor", "", "id user yang dicatat sebagai pelaku perubahan")
+	flag.Parse()
+
+	ctx := context.Background()
+	if *actor != "" {
+		ctx = belajargorm.WithActor(ctx, *actor)
+	}
+
+	cfg, err := belajargorm.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	db, err := belajargorm.Open(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	report, err := belajargorm.NewAddressService(db).DedupeAddresses(ctx, belajargorm.DedupeOptions{Merge: *merge})
+	if report != nil {
+		fmt.Println(report)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if !report.Merged && len(report.Groups) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,95 @@
+// migrate menjalankan migration schema belajargorm
+//
+//	go run ./cmd/migrate apply
+//	go run ./cmd/migrate rollback [steps]
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate redo
+//
+// koneksi database dibaca dari .env / environment variable, lihat belajargorm.LoadConfig
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	belajargorm "belajar-gorm"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate apply|rollback [steps]|status|redo")
+	}
+
+	cfg, err := belajargorm.LoadConfig()
+	if err != nil {
+		return err
+	}
+	db, err := belajargorm.Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	migrator := belajargorm.NewMigrator(db)
+
+	switch args[0] {
+	case "apply", "up":
+		done, err := migrator.Apply(ctx)
+		for _, m := range done {
+			fmt.Printf("applied  %04d %s\n", m.Version, m.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("nothing to apply")
+		}
+		return err
+
+	case "rollback", "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+		}
+		done, err := migrator.Rollback(ctx, steps)
+		for _, m := range done {
+			fmt.Printf("rolled back %04d %s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d %-40s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+
+	case "redo":
+		m, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			fmt.Println("nothing to redo")
+			return nil
+		}
+		fmt.Printf("redone   %04d %s\n", m.Version, m.Name)
+		return nil
+	}
+
+	return fmt.Errorf("unknown command %q", args[0])
+}
@@ -0,0 +1,59 @@
+// reconcile menghitung ulang saldo semua wallet dari wallet_entries dan melaporkan saldo yang berbeda
+// dengan -fix, saldo dikoreksi dan koreksinya dicatat di user_logs
+// keluar dengan status 1 jika masih ada perbedaan yang belum dikoreksi,
+// perbedaan yang sudah hilang saat akan dikoreksi tidak dihitung
+//
+//	go run ./cmd/reconcile
+//	go run ./cmd/reconcile -fix -actor 1
+//
+// koneksi database dibaca dari .env / environment variable, lihat belajargorm.LoadConfig
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	belajargorm "belajar-gorm"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "koreksi saldo yang berbeda sesuai ledger")
+	actor := flag.String("actor", "", "id user yang dicatat sebagai pelaku koreksi")
+	flag.Parse()
+
+	ctx := context.Background()
+	if *actor != "" {
+		ctx = belajargorm.WithActor(ctx, *actor)
+	}
+
+	cfg, err := belajargorm.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	db, err := belajargorm.Open(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	report, err := belajargorm.NewWalletService(db).Reconcile(ctx, belajargorm.ReconcileOptions{Fix: *fix})
+	if report != nil {
+		fmt.Println(report)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(report.UnbalancedGroups) > 0 {
+		os.Exit(1)
+	}
+	for _, d := range report.Discrepancies {
+		if d.Outstanding() {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// scheduler menjalankan transfer berulang (scheduled_transfers) yang sudah jatuh tempo
+// beberapa proses scheduler boleh berjalan bersamaan, setiap jadwal hanya dieksekusi sekali
+//
+//	go run ./cmd/scheduler
+//	go run ./cmd/scheduler -interval 30s
+//	go run ./cmd/scheduler -once
+//
+// koneksi database dibaca dari .env / environment variable, lihat belajargorm.LoadConfig
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	belajargorm "belajar-gorm"
+)
+
+func main() {
+	interval := flag.Duration("interval", time.Minute, "jeda antar pengecekan jadwal")
+	once := flag.Bool("once", false, "jalankan jadwal yang jatuh tempo satu kali lalu keluar")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := belajargorm.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	db, err := belajargorm.Open(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	service := belajargorm.NewWalletService(db)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		runs, err := service.RunScheduledTransfers(ctx)
+		for _, run := range runs {
+			fmt.Printf("%s %s %s %s\n", run.ScheduleId, run.ScheduledFor.Format(time.RFC3339), run.Status, run.Error)
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, err)
+			if *once {
+				os.Exit(1)
+			}
+		}
+		if *once {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// schemacheck membandingkan mapping semua model dengan database yang terhubung
+// dan keluar dengan status 1 jika ditemukan perbedaan, sehingga bisa dipakai di CI
+//
+//	go run ./cmd/schemacheck
+//
+// koneksi database dibaca dari .env / environment variable, lihat belajargorm.LoadConfig
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	belajargorm "belajar-gorm"
+)
+
+func main() {
+	ctx := context.Background()
+
+	cfg, err := belajargorm.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	db, err := belajargorm.Open(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	report, err := belajargorm.CheckSchema(db, belajargorm.Models()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	fmt.Println(report)
+	if report.HasDrift() {
+		os.Exit(1)
+	}
+}
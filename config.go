@@ -0,0 +1,253 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Config berisi semua pengaturan yang dibutuhkan untuk membuka koneksi database
+type Config struct {
+	// nama driver yang terdaftar lewat RegisterDialect: postgres, mysql atau sqlite
+	Driver string
+
+	// DSN lengkap, jika diisi maka Host, Port, User, Password, Name, SSLMode dan TimeZone diabaikan
+	DSN string
+
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+	TimeZone string
+
+	// pengaturan connection pool
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	LogLevel logger.LogLevel
+
+	// diteruskan ke gorm.Config
+	PrepareStmt            bool
+	SkipDefaultTransaction bool
+
+	// AuditPlugin didaftarkan secara otomatis kecuali DisableAudit bernilai true
+	DisableAudit bool
+	// AddressHistoryPlugin didaftarkan secara otomatis kecuali DisableAddressHistory bernilai true
+	DisableAddressHistory bool
+}
+
+// DefaultConfig mengembalikan Config dengan nilai bawaan yang aman untuk production
+func DefaultConfig() Config {
+	return Config{
+		Driver:          "postgres",
+		Host:            "localhost",
+		SSLMode:         "disable",
+		TimeZone:        "UTC",
+		MaxOpenConns:    25,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+		LogLevel:        logger.Warn,
+	}
+}
+
+// LoadConfig membaca file .env (jika ada) lalu membangun Config dari environment variable
+func LoadConfig() (Config, error) {
+	err := godotenv.Load()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("belajargorm: load .env: %w", err)
+	}
+	return ConfigFromEnv(os.Getenv)
+}
+
+// ConfigFromEnv membangun Config dari environment variable
+// DB tetap didukung sebagai DSN lengkap agar .env lama tetap berjalan
+func ConfigFromEnv(getenv func(string) string) (Config, error) {
+	cfg := DefaultConfig()
+	cfg.DSN = getenv("DB")
+
+	if v := getenv("DB_DRIVER"); v != "" {
+		cfg.Driver = v
+	}
+	if v := getenv("DB_HOST"); v != "" {
+		cfg.Host = v
+	}
+	if v := getenv("DB_USER"); v != "" {
+		cfg.User = v
+	}
+	if v := getenv("DB_PASSWORD"); v != "" {
+		cfg.Password = v
+	}
+	if v := getenv("DB_NAME"); v != "" {
+		cfg.Name = v
+	}
+	if v := getenv("DB_SSLMODE"); v != "" {
+		cfg.SSLMode = v
+	}
+	if v := getenv("DB_TIMEZONE"); v != "" {
+		cfg.TimeZone = v
+	}
+
+	ints := []struct {
+		key  string
+		dest *int
+	}{
+		{"DB_PORT", &cfg.Port},
+		{"DB_MAX_OPEN_CONNS", &cfg.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", &cfg.MaxIdleConns},
+	}
+	for _, i := range ints {
+		v := getenv(i.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("belajargorm: invalid %s %q: %w", i.key, v, err)
+		}
+		*i.dest = n
+	}
+
+	durations := []struct {
+		key  string
+		dest *time.Duration
+	}{
+		{"DB_CONN_MAX_LIFETIME", &cfg.ConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_TIME", &cfg.ConnMaxIdleTime},
+	}
+	for _, d := range durations {
+		v := getenv(d.key)
+		if v == "" {
+			continue
+		}
+		n, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("belajargorm: invalid %s %q: %w", d.key, v, err)
+		}
+		*d.dest = n
+	}
+
+	bools := []struct {
+		key  string
+		dest *bool
+	}{
+		{"DB_PREPARE_STMT", &cfg.PrepareStmt},
+		{"DB_SKIP_DEFAULT_TRANSACTION", &cfg.SkipDefaultTransaction},
+		{"DB_DISABLE_AUDIT", &cfg.DisableAudit},
+		{"DB_DISABLE_ADDRESS_HISTORY", &cfg.DisableAddressHistory},
+	}
+	for _, b := range bools {
+		v := getenv(b.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("belajargorm: invalid %s %q: %w", b.key, v, err)
+		}
+		*b.dest = n
+	}
+
+	if v := getenv("DB_LOG_LEVEL"); v != "" {
+		level, err := ParseLogLevel(v)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.LogLevel = level
+	}
+
+	return cfg, nil
+}
+
+// ParseLogLevel mengubah string silent, error, warn atau info menjadi logger.LogLevel
+func ParseLogLevel(s string) (logger.LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "warn", "warning":
+		return logger.Warn, nil
+	case "info":
+		return logger.Info, nil
+	}
+	return 0, fmt.Errorf("belajargorm: unknown log level %q", s)
+}
+
+// DataSourceName mengembalikan DSN yang akan dipakai oleh driver
+func (c Config) DataSourceName() (string, error) {
+	if c.DSN != "" {
+		return c.DSN, nil
+	}
+	dialect, err := LookupDialect(c.Driver)
+	if err != nil {
+		return "", err
+	}
+	return dialect.DSN(c)
+}
+
+// Open membuka koneksi database sesuai Config, mengatur connection pool
+// lalu memastikan database dapat dihubungi sebelum dikembalikan
+func Open(ctx context.Context, cfg Config) (*gorm.DB, error) {
+	dialect, err := LookupDialect(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := cfg.DataSourceName()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialect.Dialector(dsn), &gorm.Config{
+		Logger:                 logger.Default.LogMode(cfg.LogLevel),
+		PrepareStmt:            cfg.PrepareStmt,
+		SkipDefaultTransaction: cfg.SkipDefaultTransaction,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("belajargorm: open database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("belajargorm: get sql.DB: %w", err)
+	}
+
+	if !cfg.DisableAudit {
+		if err := db.Use(AuditPlugin{}); err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("belajargorm: register audit plugin: %w", err)
+		}
+	}
+
+	if !cfg.DisableAddressHistory {
+		if err := db.Use(AddressHistoryPlugin{}); err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("belajargorm: register address history plugin: %w", err)
+		}
+	}
+
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("belajargorm: ping database: %w", err)
+	}
+
+	return db, nil
+}
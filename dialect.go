@@ -0,0 +1,212 @@
+package belajargorm
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Dialect menghubungkan nama driver (nilai DB_DRIVER) dengan dialector gorm
+// dan cara membangun DSN dari Config
+type Dialect struct {
+	Dialector func(dsn string) gorm.Dialector
+	DSN       func(cfg Config) (string, error)
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{}
+)
+
+// RegisterDialect mendaftarkan dialect baru, mirip sql.Register
+// panic jika nama sudah terdaftar atau dialect tidak lengkap
+func RegisterDialect(name string, dialect Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	if dialect.Dialector == nil || dialect.DSN == nil {
+		panic("belajargorm: RegisterDialect " + name + " is incomplete")
+	}
+	if _, dup := dialects[name]; dup {
+		panic("belajargorm: RegisterDialect called twice for " + name)
+	}
+	dialects[name] = dialect
+}
+
+// LookupDialect mencari dialect berdasarkan nama driver
+func LookupDialect(name string) (Dialect, error) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	dialect, ok := dialects[name]
+	if !ok {
+		return Dialect{}, fmt.Errorf("belajargorm: unknown driver %q (registered: %v)", name, registeredDialects())
+	}
+	return dialect, nil
+}
+
+// Dialects mengembalikan nama semua driver yang terdaftar secara terurut
+func Dialects() []string {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	return registeredDialects()
+}
+
+func registeredDialects() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterDialect("postgres", Dialect{
+		Dialector: postgres.Open,
+		DSN:       postgresDSN,
+	})
+	RegisterDialect("mysql", Dialect{
+		Dialector: mysql.Open,
+		DSN:       mysqlDSN,
+	})
+	RegisterDialect("sqlite", Dialect{
+		Dialector: func(dsn string) gorm.Dialector {
+			return sqlite.New(sqlite.Config{DriverName: sqliteDriverName, DSN: dsn})
+		},
+		DSN: sqliteDSN,
+	})
+}
+
+// sqliteMath membungkus fungsi matematika untuk sqlite, argumennya boleh integer atau float dan NULL menghasilkan NULL
+func sqliteMath(f func(float64) float64) func(interface{}) interface{} {
+	return func(v interface{}) interface{} {
+		x, ok := sqliteFloat(v)
+		if !ok {
+			return nil
+		}
+		return f(x)
+	}
+}
+
+func sqliteFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+// sqliteDriverName adalah driver go-sqlite3 yang ditambah fungsi matematika,
+// yang di postgres dan mysql sudah tersedia, misalnya untuk rumus haversine di AddressesWithin
+const sqliteDriverName = "sqlite3_belajargorm"
+
+func init() {
+	sql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			functions := map[string]interface{}{
+				"sin":     sqliteMath(math.Sin),
+				"cos":     sqliteMath(math.Cos),
+				"asin":    sqliteMath(math.Asin),
+				"sqrt":    sqliteMath(math.Sqrt),
+				"radians": sqliteMath(func(degrees float64) float64 { return degrees * math.Pi / 180 }),
+				"least": func(a, b interface{}) interface{} {
+					x, okX := sqliteFloat(a)
+					y, okY := sqliteFloat(b)
+					if !okX || !okY {
+						return nil
+					}
+					return math.Min(x, y)
+				},
+			}
+			for name, impl := range functions {
+				if err := conn.RegisterFunc(name, impl, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+func postgresDSN(c Config) (string, error) {
+	u := url.URL{
+		Scheme: "postgres",
+		Host:   c.Host,
+		Path:   "/" + c.Name,
+	}
+	port := c.Port
+	if port == 0 {
+		port = 5432
+	}
+	u.Host = c.Host + ":" + strconv.Itoa(port)
+	if c.User != "" {
+		u.User = url.UserPassword(c.User, c.Password)
+	}
+	q := url.Values{}
+	if c.SSLMode != "" {
+		q.Set("sslmode", c.SSLMode)
+	}
+	if c.TimeZone != "" {
+		q.Set("TimeZone", c.TimeZone)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func mysqlDSN(c Config) (string, error) {
+	port := c.Port
+	if port == 0 {
+		port = 3306
+	}
+	loc := time.UTC
+	if c.TimeZone != "" {
+		l, err := time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return "", fmt.Errorf("belajargorm: invalid time zone %q: %w", c.TimeZone, err)
+		}
+		loc = l
+	}
+
+	mc := mysqldriver.NewConfig()
+	mc.User = c.User
+	mc.Passwd = c.Password
+	mc.Net = "tcp"
+	mc.Addr = c.Host + ":" + strconv.Itoa(port)
+	mc.DBName = c.Name
+	mc.ParseTime = true
+	mc.Loc = loc
+	mc.Params = map[string]string{"charset": "utf8mb4"}
+	return mc.FormatDSN(), nil
+}
+
+// untuk sqlite, Name berisi path file database
+// jika kosong atau ":memory:" maka memakai in-memory database yang dibagi antar koneksi
+// transaction memakai BEGIN IMMEDIATE agar transaction yang bersamaan menunggu (busy_timeout)
+// bukan langsung gagal saat berpindah dari read lock ke write lock
+func sqliteDSN(c Config) (string, error) {
+	name := c.Name
+	params := url.Values{}
+	params.Set("_foreign_keys", "1")
+	params.Set("_busy_timeout", "5000")
+	params.Set("_txlock", "immediate")
+	if name == "" || name == ":memory:" {
+		name = ":memory:"
+		params.Set("cache", "shared")
+	} else {
+		params.Set("_journal_mode", "WAL")
+	}
+	return "file:" + name + "?" + params.Encode(), nil
+}
@@ -0,0 +1,90 @@
+package belajargorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigDialects(t *testing.T) {
+	assert.Equal(t, []string{"mysql", "postgres", "sqlite"}, Dialects())
+
+	env := map[string]string{
+		"DB_DRIVER":   "mysql",
+		"DB_USER":     "root",
+		"DB_PASSWORD": "rahasia",
+		"DB_NAME":     "belajar_gorm",
+	}
+	cfg, err := ConfigFromEnv(func(key string) string { return env[key] })
+	assert.Nil(t, err)
+	dsn, err := cfg.DataSourceName()
+	assert.Nil(t, err)
+	assert.Equal(t, "root:rahasia@tcp(localhost:3306)/belajar_gorm?parseTime=true&charset=utf8mb4", dsn)
+
+	cfg = Config{Driver: "sqlite", Name: "belajar_gorm.db"}
+	dsn, err = cfg.DataSourceName()
+	assert.Nil(t, err)
+	assert.Equal(t, "file:belajar_gorm.db?_busy_timeout=5000&_foreign_keys=1&_journal_mode=WAL&_txlock=immediate", dsn)
+
+	cfg = Config{Driver: "oracle"}
+	_, err = cfg.DataSourceName()
+	assert.NotNil(t, err)
+}
+
+// model harus berperilaku sama di semua driver, driver dipilih lewat TEST_DB_DRIVER dan TEST_DB_DSN seperti newTestDB
+// schema dibuat dengan AutoMigrate dari model di database/schema baru, bukan lewat migration
+func TestDialectModels(t *testing.T) {
+	t.Parallel()
+	db := openTestDB(t)
+	err := db.AutoMigrate(Models()...)
+	assert.Nil(t, err)
+
+	user := User{
+		ID:       "1",
+		Password: "rahasia",
+		Name:     Name{FirstName: "Eko"},
+		Wallet:   Wallet{ID: "1", Balance: 1000000},
+		Addresses: []Address{
+			{Address: "Jalan A"},
+		},
+	}
+	err = db.Create(&user).Error
+	assert.Nil(t, err)
+
+	var result User
+	err = db.Preload("Addresses").Joins("Wallet").Take(&result, "users.id = ?", "1").Error
+	assert.Nil(t, err)
+	assert.Equal(t, "Eko", result.Name.FirstName)
+	assert.Equal(t, int64(1000000), result.Wallet.Balance)
+	assert.Equal(t, 1, len(result.Addresses))
+	assert.NotZero(t, result.Addresses[0].ID)
+
+	// wallet kedua yang bukan default tidak boleh ditolak unique index
+	err = db.Create(&Wallet{ID: "2", UserId: "1"}).Error
+	assert.Nil(t, err)
+	var wallets []Wallet
+	err = db.Order("id").Find(&wallets, "user_id = ?", "1").Error
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(wallets)) {
+		assert.True(t, wallets[0].IsDefault)
+		assert.False(t, wallets[1].IsDefault)
+	}
+
+	// begitu juga alamat kedua dengan type yang sama yang bukan primary
+	err = db.Create(&Address{UserId: "1", Address: "Jalan B"}).Error
+	assert.Nil(t, err)
+	var primary int64
+	err = db.Model(&Address{}).Where("user_id = ? AND is_primary = ?", "1", true).Count(&primary).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), primary)
+
+	todo := Todo{UserId: "1", Title: "Todo 1"}
+	err = db.Create(&todo).Error
+	assert.Nil(t, err)
+	err = db.Delete(&todo).Error
+	assert.Nil(t, err)
+	var count int64
+	err = db.Model(&Todo{}).Count(&count).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,93 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrExchangeRateNotFound = errors.New("belajargorm: exchange rate not found")
+
+// ExchangeRate adalah kurs 1 unit BaseCurrency dalam QuoteCurrency yang berlaku mulai EffectiveAt
+// Rate disimpan sebagai string desimal (contoh "15750.25") agar tidak kehilangan presisi seperti float
+type ExchangeRate struct {
+	ID            uint      `gorm:"primaryKey;column:id;autoIncrement"`
+	BaseCurrency  string    `gorm:"column:base_currency;size:3;not null;index:idx_exchange_rates_pair,priority:1"`
+	QuoteCurrency string    `gorm:"column:quote_currency;size:3;not null;index:idx_exchange_rates_pair,priority:2"`
+	Rate          string    `gorm:"column:rate;size:40;not null"`
+	EffectiveAt   time.Time `gorm:"column:effective_at;not null;index:idx_exchange_rates_pair,priority:3"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (r *ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+// Ratio mengembalikan Rate sebagai bilangan rasional
+func (r *ExchangeRate) Ratio() (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(r.Rate)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("belajargorm: invalid exchange rate %q", r.Rate)
+	}
+	return rate, nil
+}
+
+// hook BeforeSave memvalidasi mata uang dan rate, EffectiveAt disimpan dalam UTC
+func (r *ExchangeRate) BeforeSave(tx *gorm.DB) error {
+	if _, err := CurrencyExponent(r.BaseCurrency); err != nil {
+		return err
+	}
+	if _, err := CurrencyExponent(r.QuoteCurrency); err != nil {
+		return err
+	}
+	if r.BaseCurrency == r.QuoteCurrency {
+		return fmt.Errorf("belajargorm: exchange rate %s/%s has the same currency", r.BaseCurrency, r.QuoteCurrency)
+	}
+	if _, err := r.Ratio(); err != nil {
+		return err
+	}
+	if r.EffectiveAt.IsZero() {
+		r.EffectiveAt = time.Now()
+	}
+	r.EffectiveAt = r.EffectiveAt.UTC()
+	return nil
+}
+
+// ExchangeRateAt mencari kurs base ke quote terakhir yang berlaku pada waktu at
+// jika hanya ada kurs quote ke base, kurs tersebut dibalik
+func ExchangeRateAt(ctx context.Context, db *gorm.DB, base, quote string, at time.Time) (*big.Rat, error) {
+	if base == quote {
+		return big.NewRat(1, 1), nil
+	}
+	find := func(base, quote string) (*big.Rat, error) {
+		var rate ExchangeRate
+		err := db.WithContext(ctx).
+			Where("base_currency = ? AND quote_currency = ? AND effective_at <= ?", base, quote, at.UTC()).
+			Order("effective_at DESC").
+			Take(&rate).Error
+		if err != nil {
+			return nil, err
+		}
+		return rate.Ratio()
+	}
+
+	rate, err := find(base, quote)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	inverse, err := find(quote, base)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s/%s at %s", ErrExchangeRateNotFound, base, quote, at.UTC().Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inverse.Inv(inverse), nil
+}
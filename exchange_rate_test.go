@@ -0,0 +1,105 @@
+package belajargorm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRateAt(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.Nil(t, db.Create(&ExchangeRate{BaseCurrency: "USD", QuoteCurrency: "IDR", Rate: "15000", EffectiveAt: now.Add(-48 * time.Hour)}).Error)
+	assert.Nil(t, db.Create(&ExchangeRate{BaseCurrency: "USD", QuoteCurrency: "IDR", Rate: "16000.5", EffectiveAt: now.Add(-time.Hour)}).Error)
+	assert.Nil(t, db.Create(&ExchangeRate{BaseCurrency: "USD", QuoteCurrency: "IDR", Rate: "17000", EffectiveAt: now.Add(time.Hour)}).Error)
+
+	rate, err := ExchangeRateAt(ctx, db, "USD", "IDR", now)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewRat(32001, 2), rate)
+
+	rate, err = ExchangeRateAt(ctx, db, "USD", "IDR", now.Add(-24*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewRat(15000, 1), rate)
+
+	// kurs kebalikan dihitung dari USD/IDR
+	rate, err = ExchangeRateAt(ctx, db, "IDR", "USD", now)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewRat(2, 32001), rate)
+
+	_, err = ExchangeRateAt(ctx, db, "USD", "EUR", now)
+	assert.ErrorIs(t, err, ErrExchangeRateNotFound)
+
+	assert.NotNil(t, db.Create(&ExchangeRate{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: "-1"}).Error)
+	assert.NotNil(t, db.Create(&ExchangeRate{BaseCurrency: "USD", QuoteCurrency: "USD", Rate: "1"}).Error)
+	assert.NotNil(t, db.Create(&ExchangeRate{BaseCurrency: "USD", QuoteCurrency: "XXX", Rate: "1"}).Error)
+}
+
+func TestTransferAcrossCurrencies(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	assert.Nil(t, db.Create(&Wallet{ID: "2", UserId: "2", Currency: "USD", Balance: 10000}).Error)
+	assert.Nil(t, db.Create(&ExchangeRate{BaseCurrency: "USD", QuoteCurrency: "IDR", Rate: "15000", EffectiveAt: time.Now().Add(-time.Minute)}).Error)
+
+	// 10.00 USD = 150000.00 IDR
+	assert.Nil(t, service.Transfer(ctx, "2", "1", 1000))
+	assert.Equal(t, int64(9000), walletBalance(t, db, "2"))
+	assert.Equal(t, int64(1000000+15000000), walletBalance(t, db, "1"))
+
+	// 1000.00 IDR = 0.07 USD (0.0666.. dibulatkan)
+	assert.Nil(t, service.Transfer(ctx, "1", "2", 100000))
+	assert.Equal(t, int64(9007), walletBalance(t, db, "2"))
+
+	fx, err := LedgerBalanceIn(ctx, db, AccountFX, "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 993, Currency: "USD"}, fx)
+
+	var wallet Wallet
+	assert.Nil(t, db.Take(&wallet, "id = ?", "2").Error)
+	assert.Equal(t, "USD 90.07", wallet.BalanceMoney().String())
+
+	// mata uang wallet tidak bisa diubah setelah dibuat
+	wallet.Currency = "IDR"
+	assert.Nil(t, db.Save(&wallet).Error)
+	assert.Nil(t, db.Take(&wallet, "id = ?", "2").Error)
+	assert.Equal(t, "USD", wallet.Currency)
+
+	assert.Nil(t, db.Create(&Wallet{ID: "3", UserId: "3", Currency: "EUR"}).Error)
+	assert.ErrorIs(t, service.Transfer(ctx, "3", "1", 0), ErrInvalidAmount)
+	assert.Nil(t, service.Deposit(ctx, "3", 100, "topup"))
+	assert.ErrorIs(t, service.Transfer(ctx, "3", "1", 100), ErrExchangeRateNotFound)
+	assert.NotNil(t, db.Create(&Wallet{ID: "4", UserId: "4", Currency: "XXX"}).Error)
+	assertLedgerConsistent(t, db)
+}
+
+func TestMultiCurrencyMigration(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+	before := walletBalance(t, db, "1")
+
+	// kembali ke schema sebelum multi currency, saldo disimpan dalam rupiah penuh
+	done, err := migrator.Rollback(ctx, len(Migrations())-4)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), done[len(done)-1].Version)
+	var balance, entries int64
+	assert.Nil(t, db.Table("wallets").Select("balance").Where("id = ?", "1").Row().Scan(&balance))
+	assert.Equal(t, before/100, balance)
+	assert.Nil(t, db.Table("wallet_entries").Select("SUM(amount)").Where("account = ?", "1").Row().Scan(&entries))
+	assert.Equal(t, before/100, entries)
+
+	// saldo lama dibaca sebagai IDR dalam sen
+	_, err = migrator.Apply(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, before, walletBalance(t, db, "1"))
+	assertLedgerConsistent(t, db)
+}
@@ -0,0 +1,203 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// earthRadiusKm adalah jari-jari rata-rata bumi yang dipakai rumus haversine
+const earthRadiusKm = 6371.0
+
+// kmPerDegree adalah jarak satu derajat lintang, juga satu derajat bujur di khatulistiwa
+const kmPerDegree = math.Pi * earthRadiusKm / 180
+
+var (
+	ErrAddressNotGeocoded = errors.New("belajargorm: address could not be geocoded")
+	ErrInvalidCoordinates = errors.New("belajargorm: invalid coordinates")
+)
+
+// Coordinates adalah titik lintang (Latitude) dan bujur (Longitude) dalam derajat desimal
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func (c Coordinates) valid() bool {
+	return c.Latitude >= -90 && c.Latitude <= 90 && c.Longitude >= -180 && c.Longitude <= 180
+}
+
+// DistanceTo menghitung jarak ke other dalam kilometer dengan rumus haversine,
+// sama dengan rumus SQL di haversineSQL
+func (c Coordinates) DistanceTo(other Coordinates) float64 {
+	lat1, lat2 := c.Latitude*math.Pi/180, other.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (other.Longitude - c.Longitude) * math.Pi / 180
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Geocoder mencari koordinat dari bagian-bagian alamat
+// kembalikan ErrAddressNotGeocoded jika alamat tidak ditemukan
+type Geocoder interface {
+	Geocode(ctx context.Context, address AddressFields) (Coordinates, error)
+}
+
+// StaticGeocoder adalah Geocoder lokal untuk test dan development, tanpa layanan luar
+// key berisi kode pos atau nama kota, dicocokkan setelah NormalizeAddress
+// kode pos didahulukan, koordinat yang dihasilkan hanya titik tengah kota atau kode pos tersebut
+//
+//	StaticGeocoder{"40115": {-6.9147, 107.6098}, "jakarta selatan": {-6.2615, 106.8106}}
+type StaticGeocoder map[string]Coordinates
+
+func (g StaticGeocoder) Geocode(_ context.Context, address AddressFields) (Coordinates, error) {
+	normalized := make(map[string]Coordinates, len(g))
+	for key, coordinates := range g {
+		normalized[NormalizeAddress(key)] = coordinates
+	}
+	for _, key := range []string{address.PostalCode, address.City} {
+		if coordinates, ok := normalized[NormalizeAddress(key)]; ok && key != "" {
+			return coordinates, nil
+		}
+	}
+	return Coordinates{}, fmt.Errorf("%w: %q", ErrAddressNotGeocoded, address.String())
+}
+
+// GeocodePlugin mengisi Latitude dan Longitude Address yang masih kosong saat create memakai Geocoder
+// dijalankan setelah hook BeforeCreate sehingga Fields sudah terisi dari teks alamat
+// alamat yang tidak ditemukan (ErrAddressNotGeocoded) tetap disimpan tanpa koordinat,
+// error lain dari Geocoder membatalkan create
+//
+//	db.Use(GeocodePlugin{Geocoder: StaticGeocoder{...}})
+type GeocodePlugin struct {
+	Geocoder Geocoder
+}
+
+func (GeocodePlugin) Name() string {
+	return "belajargorm:geocode"
+}
+
+func (p GeocodePlugin) Initialize(db *gorm.DB) error {
+	if p.Geocoder == nil {
+		return errors.New("belajargorm: GeocodePlugin requires a Geocoder")
+	}
+	return db.Callback().Create().After("gorm:before_create").Before("gorm:create").
+		Register("belajargorm:geocode", p.geocode)
+}
+
+func (p GeocodePlugin) geocode(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.Table != "addresses" {
+		return
+	}
+	geocode := func(value reflect.Value) {
+		address, ok := value.Addr().Interface().(*Address)
+		if !ok || address.Latitude != nil || address.Longitude != nil {
+			return
+		}
+		coordinates, err := p.Geocoder.Geocode(db.Statement.Context, address.Fields)
+		if errors.Is(err, ErrAddressNotGeocoded) {
+			return
+		}
+		if err != nil {
+			db.AddError(fmt.Errorf("belajargorm: geocode: %w", err))
+			return
+		}
+		address.Latitude, address.Longitude = &coordinates.Latitude, &coordinates.Longitude
+	}
+
+	switch value := reflect.Indirect(db.Statement.ReflectValue); value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len() && db.Error == nil; i++ {
+			if elem := reflect.Indirect(value.Index(i)); elem.Kind() == reflect.Struct {
+				geocode(elem)
+			}
+		}
+	case reflect.Struct:
+		if value.CanAddr() {
+			geocode(value)
+		}
+	}
+}
+
+// NearbyAddress adalah Address beserta jaraknya dalam kilometer dari titik yang dicari
+type NearbyAddress struct {
+	Address
+	DistanceKm float64 `gorm:"column:distance_km;->"`
+}
+
+// haversineSQL adalah rumus haversine dalam SQL biasa (tanpa PostGIS), hasilnya dalam kilometer
+// LEAST menjaga nilai asin tidak lebih dari 1 karena pembulatan, di sqlite fungsinya didaftarkan oleh sqliteDriverName
+const haversineSQL = "2 * ? * ASIN(LEAST(1, SQRT(" +
+	"SIN(RADIANS(latitude - ?) / 2) * SIN(RADIANS(latitude - ?) / 2) + " +
+	"COS(RADIANS(?)) * COS(RADIANS(latitude)) * SIN(RADIANS(longitude - ?) / 2) * SIN(RADIANS(longitude - ?) / 2))))"
+
+func haversineArgs(c Coordinates) []interface{} {
+	return []interface{}{earthRadiusKm, c.Latitude, c.Latitude, c.Latitude, c.Longitude, c.Longitude}
+}
+
+// AddressesWithin mengembalikan alamat yang berjarak paling jauh km dari (lat, lng), diurutkan dari yang terdekat
+// alamat tanpa koordinat diabaikan
+func (s *AddressService) AddressesWithin(ctx context.Context, lat, lng, km float64) ([]NearbyAddress, error) {
+	center := Coordinates{Latitude: lat, Longitude: lng}
+	if !center.valid() || km < 0 || math.IsNaN(km) {
+		return nil, fmt.Errorf("%w: (%v, %v) within %v km", ErrInvalidCoordinates, lat, lng, km)
+	}
+	return s.within(ctx, center, km, 0)
+}
+
+// NearestAddresses mengembalikan n alamat terdekat dari (lat, lng), diurutkan dari yang terdekat
+// radius pencarian dimulai dari 1 km dan digandakan sampai ditemukan n alamat,
+// sehingga bounding box tetap memakai idx_addresses_location
+func (s *AddressService) NearestAddresses(ctx context.Context, lat, lng float64, n int) ([]NearbyAddress, error) {
+	center := Coordinates{Latitude: lat, Longitude: lng}
+	if !center.valid() {
+		return nil, fmt.Errorf("%w: (%v, %v)", ErrInvalidCoordinates, lat, lng)
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	for km := 1.0; ; km *= 2 {
+		addresses, err := s.within(ctx, center, km, n)
+		if err != nil {
+			return nil, err
+		}
+		// setengah keliling bumi adalah jarak terjauh, bounding box-nya sudah mencakup semua alamat
+		if len(addresses) >= n || km >= math.Pi*earthRadiusKm {
+			return addresses, nil
+		}
+	}
+}
+
+// within menyaring alamat dengan bounding box lebih dulu agar index bisa dipakai,
+// lalu menghitung jarak sebenarnya dengan haversineSQL, limit 0 berarti tanpa batas
+func (s *AddressService) within(ctx context.Context, center Coordinates, km float64, limit int) ([]NearbyAddress, error) {
+	query := s.db.WithContext(ctx).Model(&Address{}).
+		Select("addresses.*, "+haversineSQL+" AS distance_km", haversineArgs(center)...).
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Where(haversineSQL+" <= ?", append(haversineArgs(center), km)...)
+
+	dLat := km / kmPerDegree
+	minLat, maxLat := center.Latitude-dLat, center.Latitude+dLat
+	query = query.Where("latitude BETWEEN ? AND ?", math.Max(minLat, -90), math.Min(maxLat, 90))
+	// batas bujur hanya dipakai jika kotak tidak melewati kutub atau garis bujur 180
+	if minLat > -90 && maxLat < 90 {
+		dLng := dLat / math.Cos(center.Latitude*math.Pi/180)
+		if minLng, maxLng := center.Longitude-dLng, center.Longitude+dLng; minLng >= -180 && maxLng <= 180 {
+			query = query.Where("longitude BETWEEN ? AND ?", minLng, maxLng)
+		}
+	}
+
+	query = query.Order("distance_km").Order("addresses.id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var addresses []NearbyAddress
+	if err := query.Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
@@ -0,0 +1,164 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	monas    = Coordinates{Latitude: -6.1754, Longitude: 106.8272}
+	blokM    = Coordinates{Latitude: -6.2443, Longitude: 106.7982}
+	bogor    = Coordinates{Latitude: -6.5950, Longitude: 106.8166}
+	bandung  = Coordinates{Latitude: -6.9147, Longitude: 107.6098}
+	surabaya = Coordinates{Latitude: -7.2575, Longitude: 112.7521}
+)
+
+func TestCoordinatesDistance(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 0, monas.DistanceTo(monas), 0.001)
+	assert.InDelta(t, 8.3, monas.DistanceTo(blokM), 0.1)
+	assert.InDelta(t, 119.3, monas.DistanceTo(bandung), 0.1)
+	assert.InDelta(t, monas.DistanceTo(surabaya), surabaya.DistanceTo(monas), 0.001)
+	// titik yang berseberangan, setengah keliling bumi
+	assert.InDelta(t, 20015.1, Coordinates{0, 0}.DistanceTo(Coordinates{0, 180}), 0.1)
+}
+
+func createAddressAt(t *testing.T, service *AddressService, userID, text string, at Coordinates) Address {
+	t.Helper()
+	address := Address{UserId: userID, Address: text, Latitude: &at.Latitude, Longitude: &at.Longitude}
+	assert.Nil(t, service.db.Create(&address).Error)
+	return address
+}
+
+func TestAddressesWithin(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := context.Background()
+
+	blokMAddress := createAddressAt(t, service, "1", "Jalan Blok M", blokM)
+	bogorAddress := createAddressAt(t, service, "2", "Jalan Bogor", bogor)
+	createAddressAt(t, service, "3", "Jalan Bandung", bandung)
+	createAddressAt(t, service, "4", "Jalan Surabaya", surabaya)
+	// alamat tanpa koordinat diabaikan
+	assert.Nil(t, db.Create(&Address{UserId: "5", Address: "Jalan Tanpa Koordinat"}).Error)
+
+	addresses, err := service.AddressesWithin(ctx, monas.Latitude, monas.Longitude, 50)
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(addresses)) {
+		assert.Equal(t, blokMAddress.ID, addresses[0].ID)
+		assert.Equal(t, "Jalan Blok M", addresses[0].Address.Address)
+		assert.InDelta(t, monas.DistanceTo(blokM), addresses[0].DistanceKm, 0.01)
+		assert.Equal(t, bogorAddress.ID, addresses[1].ID)
+		assert.InDelta(t, monas.DistanceTo(bogor), addresses[1].DistanceKm, 0.01)
+	}
+
+	addresses, err = service.AddressesWithin(ctx, monas.Latitude, monas.Longitude, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(addresses))
+
+	addresses, err = service.AddressesWithin(ctx, monas.Latitude, monas.Longitude, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(addresses))
+
+	_, err = service.AddressesWithin(ctx, 91, 0, 10)
+	assert.ErrorIs(t, err, ErrInvalidCoordinates)
+	_, err = service.AddressesWithin(ctx, 0, 0, -1)
+	assert.ErrorIs(t, err, ErrInvalidCoordinates)
+}
+
+func TestNearestAddresses(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewAddressService(db)
+	ctx := context.Background()
+
+	surabayaAddress := createAddressAt(t, service, "1", "Jalan Surabaya", surabaya)
+	bandungAddress := createAddressAt(t, service, "2", "Jalan Bandung", bandung)
+	createAddressAt(t, service, "3", "Jalan Blok M", blokM)
+	// di seberang garis bujur 180
+	createAddressAt(t, service, "4", "Fiji", Coordinates{Latitude: -17.7134, Longitude: 178.0650})
+
+	addresses, err := service.NearestAddresses(ctx, surabaya.Latitude, surabaya.Longitude+0.01, 2)
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(addresses)) {
+		assert.Equal(t, surabayaAddress.ID, addresses[0].ID)
+		assert.InDelta(t, 1.1, addresses[0].DistanceKm, 0.1)
+		assert.Equal(t, bandungAddress.ID, addresses[1].ID)
+	}
+
+	// n lebih besar dari jumlah alamat, semua alamat dikembalikan
+	addresses, err = service.NearestAddresses(ctx, -17, -179.9, 10)
+	assert.Nil(t, err)
+	if assert.Equal(t, 4, len(addresses)) {
+		assert.Equal(t, "Fiji", addresses[0].Address.Address)
+	}
+
+	addresses, err = service.NearestAddresses(ctx, 0, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(addresses))
+}
+
+type failingGeocoder struct{}
+
+func (failingGeocoder) Geocode(context.Context, AddressFields) (Coordinates, error) {
+	return Coordinates{}, errors.New("geocoder unavailable")
+}
+
+func TestGeocodePlugin(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	assert.Nil(t, db.Use(GeocodePlugin{Geocoder: StaticGeocoder{
+		"40115":           bandung,
+		"Jakarta Selatan": blokM,
+	}}))
+
+	addresses := []Address{
+		{UserId: "1", Address: "Jl. Merdeka No. 5, Bandung 40115"},
+		{UserId: "2", Address: "Jl. Sudirman No. 12, Kota Jakarta Selatan"},
+		{UserId: "3", Address: "Jalan Entah Dimana"},
+		{UserId: "4", Address: "Jalan Surabaya", Latitude: &surabaya.Latitude, Longitude: &surabaya.Longitude},
+	}
+	assert.Nil(t, db.Create(&addresses).Error)
+
+	var stored []Address
+	assert.Nil(t, db.Order("id").Find(&stored, "user_id IN ?", []string{"1", "2", "3", "4"}).Error)
+	if assert.Equal(t, 4, len(stored)) {
+		assert.Equal(t, bandung.Latitude, *stored[0].Latitude)
+		assert.Equal(t, bandung.Longitude, *stored[0].Longitude)
+		assert.Equal(t, blokM.Latitude, *stored[1].Latitude)
+		assert.Nil(t, stored[2].Latitude)
+		assert.Equal(t, surabaya.Latitude, *stored[3].Latitude)
+	}
+
+	failing := newTestDB(t)
+	assert.Nil(t, failing.Use(GeocodePlugin{Geocoder: failingGeocoder{}}))
+	assert.NotNil(t, failing.Create(&Address{UserId: "1", Address: "Jalan A"}).Error)
+	assert.NotNil(t, db.Use(GeocodePlugin{}))
+}
+
+func TestAddressCoordinatesMigration(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	// 16 address versions, 15 address coordinates
+	done, err := migrator.Rollback(ctx, 2)
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(done)) {
+		assert.Equal(t, int64(16), done[0].Version)
+		assert.Equal(t, int64(15), done[1].Version)
+	}
+	assert.False(t, db.Migrator().HasColumn(&Address{}, "latitude"))
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_fingerprint"))
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_primary"))
+
+	_, err = migrator.Apply(ctx)
+	assert.Nil(t, err)
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_location"))
+}
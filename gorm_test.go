@@ -2,46 +2,30 @@ package belajargorm
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"testing"
 
-	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
-	"gorm.io/gorm/logger"
 )
 
-func OpenConnection() *gorm.DB {
-	err := godotenv.Load()
-	if err != nil {
-		panic(err)
-	}
-	url := os.Getenv("DB")
-	dialect := postgres.Open(url)
-	db, err := gorm.Open(dialect, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
-	if err != nil {
-		panic(err)
-	}
-	return db
-}
-
-var db = OpenConnection()
-
 func TestOpenConnection(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	assert.NotNil(t, db)
 }
 
 // Exec digunakan untuk memanipulasi data
 func TestExecuteSQL(t *testing.T) {
-	err := db.Exec("INSERT INTO sample (id, name) VALUES (?, ?)", "1", "Eko").Error
+	t.Parallel()
+	db := newTestDB(t)
+
+	err := db.Exec("INSERT INTO sample (id, name) VALUES (?, ?)", "3", "Joko").Error
 	assert.Nil(t, err)
 
-	err = db.Exec("INSERT INTO sample (id, name) VALUES (?, ?)", "2", "Budi").Error
+	err = db.Exec("INSERT INTO sample (id, name) VALUES (?, ?)", "4", "Rully").Error
 	assert.Nil(t, err)
 }
 
@@ -52,6 +36,9 @@ type Sample struct {
 
 // Raw digunakan untuk melakukan query
 func TestRawSQL(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var sample Sample
 	err := db.Raw("select id, name from sample where id = ?", "1").Scan(&sample).Error
 	assert.Nil(t, err)
@@ -64,6 +51,9 @@ func TestRawSQL(t *testing.T) {
 }
 
 func TestSQLRow(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	// Rows digunakan untuk mendapatkan hasil sebagai *sql.Rows
 	rows, err := db.Raw("select id, name from sample").Rows()
 	assert.Nil(t, err)
@@ -89,6 +79,9 @@ func TestSQLRow(t *testing.T) {
 }
 
 func TestScanRow(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	// Rows digunakan untuk mendapatkan hasil sebagai *sql.Rows
 	rows, err := db.Raw("select id, name from sample").Rows()
 	assert.Nil(t, err)
@@ -106,8 +99,11 @@ func TestScanRow(t *testing.T) {
 
 // Create => memasukkan/membuat data ke database satu data satu query
 func TestCreateUser(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{
-		ID:       "1",
+		ID:       "50",
 		Password: "rahasia",
 		Name: Name{
 			FirstName:  "Eko",
@@ -126,6 +122,9 @@ func TestCreateUser(t *testing.T) {
 // Create(slices) => memasukkan banyak data
 // CreateInBatches(slices, sizes) => memasukkan banyak data sekaligus artinya banyak data satu query
 func TestBatchInsert(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []User
 	// for i := 2; i < 10; i++ {
 	// 	users = append(users, User{
@@ -158,6 +157,9 @@ func TestBatchInsert(t *testing.T) {
 // hanya bisa terjadi kalau menggunakan koneksi database yang sama
 // bisa digunakan menggunakan method Transaction
 func TestTransactionSuccess(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	err := db.Transaction(func(tx *gorm.DB) error {
 		err := tx.Create(&User{ID: "10", Password: "rahasia", Name: Name{FirstName: "User 10"}}).Error
 		if err != nil {
@@ -181,14 +183,17 @@ func TestTransactionSuccess(t *testing.T) {
 }
 
 func TestTransactionRollback(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	err := db.Transaction(func(tx *gorm.DB) error {
 		err := tx.Create(&User{ID: "13", Password: "rahasia", Name: Name{FirstName: "User 13"}}).Error
 		if err != nil {
 			return err
 		}
 
-		// ini error dan akan menyebabkan rollback
-		err = tx.Create(&User{ID: "11", Password: "rahasia", Name: Name{FirstName: "User 11"}}).Error
+		// ini error karena user 1 sudah ada dan akan menyebabkan rollback
+		err = tx.Create(&User{ID: "1", Password: "rahasia", Name: Name{FirstName: "User 1"}}).Error
 		if err != nil {
 			return err
 		}
@@ -197,11 +202,19 @@ func TestTransactionRollback(t *testing.T) {
 	})
 
 	assert.NotNil(t, err)
+
+	var count int64
+	err = db.Model(&User{}).Where("id = ?", "13").Count(&count).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
 }
 
 // manual transaction
 // ini tidak direkomendasikan
 func TestManualTransactionSuccess(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	tx := db.Begin()
 	defer tx.Rollback()
 
@@ -214,25 +227,43 @@ func TestManualTransactionSuccess(t *testing.T) {
 	if err == nil {
 		tx.Commit()
 	}
+
+	var count int64
+	err = db.Model(&User{}).Where("id in ?", []string{"13", "14"}).Count(&count).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
 }
 
 func TestManualTransactionRollback(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	tx := db.Begin()
 	defer tx.Rollback()
 
 	err := tx.Create(&User{ID: "15", Password: "rahasia", Name: Name{FirstName: "User 15"}}).Error
 	assert.Nil(t, err)
 
-	err = tx.Create(&User{ID: "14", Password: "rahasia", Name: Name{FirstName: "User 14"}}).Error
-	assert.Nil(t, err)
+	// user 1 sudah ada sehingga commit tidak dilakukan
+	err = tx.Create(&User{ID: "1", Password: "rahasia", Name: Name{FirstName: "User 1"}}).Error
+	assert.NotNil(t, err)
 
 	if err == nil {
 		tx.Commit()
 	}
+	tx.Rollback()
+
+	var count int64
+	err = db.Model(&User{}).Where("id = ?", "15").Count(&count).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
 }
 
 // query that returns single object
 func TestQuerySingleObject(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{}
 	// First => mereturn single dalam keadaan terurut berdasarkan id
 	err := db.First(&user).Error
@@ -249,6 +280,9 @@ func TestQuerySingleObject(t *testing.T) {
 // inline condition
 // akan otomatis menjadi kondisi where di sql selectnya
 func TestQuerySingleObjectInlineCondition(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{}
 	// inline condition
 	err := db.Take(&user, "id = ?", "5").Error
@@ -258,6 +292,9 @@ func TestQuerySingleObjectInlineCondition(t *testing.T) {
 }
 
 func TestQueryAllObjects(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []User
 	// inline parameter dapat berupa slice
 	err := db.Find(&users, "id in ?", []string{"1", "2", "3", "4"}).Error
@@ -266,33 +303,42 @@ func TestQueryAllObjects(t *testing.T) {
 }
 
 func TestQueryCondition(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []User
 	// Where digunakan sebelum Find
 	// ketika menggunakan where, maka query akan dianggap menggunakan operator AND SQL
-	err := db.Where("first_name like ?", "%User%").Where("password = ?", "rahasia").Find(&users).Error
+	err := db.Where("first_name like ?", "%User%").Where("last_name = ?", "").Find(&users).Error
 	assert.Nil(t, err)
 	for _, user := range users {
 		fmt.Println("user >> ", user.Name.FirstName)
 	}
-	assert.Equal(t, 13, len(users))
+	assert.Equal(t, 8, len(users))
 }
 
 func TestOrOperator(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []User
 	// operator OR SQL dari method Or
-	err := db.Where("first_name like ?", "%User%").Or("password = ?", "rahasia").Find(&users).Error
+	err := db.Where("first_name like ?", "%User%").Or("middle_name = ?", "Kurniawan").Find(&users).Error
 	assert.Nil(t, err)
 	for _, user := range users {
 		fmt.Println("user >> ", user.Name.FirstName)
 	}
-	assert.Equal(t, 14, len(users))
+	assert.Equal(t, 9, len(users))
 }
 
 func TestNotOperator(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []User
 	// operator NOT SQL dari method Not
-	// SELECT * FROM "users" WHERE NOT first_name like '%User%' AND password = 'rahasia'
-	err := db.Not("first_name like ?", "%User%").Where("password = ?", "rahasia").Find(&users).Error
+	// SELECT * FROM "users" WHERE NOT first_name like '%User%' AND middle_name = 'Kurniawan'
+	err := db.Not("first_name like ?", "%User%").Where("middle_name = ?", "Kurniawan").Find(&users).Error
 	assert.Nil(t, err)
 	for _, user := range users {
 		fmt.Println("user >> ", user.Name.FirstName)
@@ -301,6 +347,9 @@ func TestNotOperator(t *testing.T) {
 }
 
 func TestSelectFields(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []User
 	// method Select digunakan untuk menentukan kolom apa saja yang akan dibaca
 	// SELECT "id","first_name" FROM "users"
@@ -312,28 +361,34 @@ func TestSelectFields(t *testing.T) {
 		assert.NotEqual(t, "", user.Name.FirstName)
 	}
 
-	assert.Equal(t, 14, len(users))
+	assert.Equal(t, 9, len(users))
 }
 
 func TestStructCondition(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	userCondition := User{
 		Name: Name{
 			FirstName: "User 5",
 			LastName:  "", // tidak bisa, karena dianggap default value
 		},
-		Password: "rahasia",
+		ID: "5", // password tidak bisa dipakai sebagai kondisi karena disimpan dalam bentuk hash
 	}
 
 	var users []User
 	// field atau key akan menjadi nama kolom
 	// value struct akan menjadi value query
-	// SELECT * FROM "users" WHERE "users"."password" = 'rahasia' AND "users"."first_name" = 'User 5'
+	// SELECT * FROM "users" WHERE "users"."id" = '5' AND "users"."first_name" = 'User 5'
 	err := db.Where(userCondition).Find(&users).Error
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(users))
 }
 
 func TestMapCondition(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	mapCondition := map[string]interface{}{
 		"middle_name": "", // meskipun berisi string kosong tetap dianggap sebagai nilai query
 		"last_name":   "", // meskipun berisi string kosong tetap dianggap sebagai nilai query
@@ -343,10 +398,13 @@ func TestMapCondition(t *testing.T) {
 	//  SELECT * FROM "users" WHERE "last_name" = '' AND "middle_name" = ''
 	err := db.Where(mapCondition).Find(&users).Error
 	assert.Nil(t, err)
-	assert.Equal(t, 13, len(users))
+	assert.Equal(t, 8, len(users))
 }
 
 func TestOrderLimitOffset(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []User
 	// Order => untuk melakukan sorting
 	// Limit dan Offset => untuk melakukan paging
@@ -356,7 +414,7 @@ func TestOrderLimitOffset(t *testing.T) {
 	for _, user := range users {
 		fmt.Println("user >> ", user.Name.FirstName)
 	}
-	assert.Equal(t, 5, len(users))
+	assert.Equal(t, 4, len(users))
 }
 
 type UserResponse struct {
@@ -366,11 +424,14 @@ type UserResponse struct {
 }
 
 func TestQueryNonModel(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []UserResponse
 	// menyimpan hasil query model User ke data yang bertipe bukan model, dalam hal ini struct UserResponse
 	err := db.Model(&User{}).Select("id", "first_name", "last_name").Find(&users).Error
 	assert.Nil(t, err)
-	assert.Equal(t, 14, len(users))
+	assert.Equal(t, 9, len(users))
 	for _, user := range users {
 		fmt.Println("user >> ", user)
 	}
@@ -378,6 +439,9 @@ func TestQueryNonModel(t *testing.T) {
 
 // Save mengubah secara keseluruhan
 func TestUpdate(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{}
 	err := db.Take(&user, "id = ?", "1").Error
 	assert.Nil(t, err)
@@ -396,6 +460,9 @@ func TestUpdate(t *testing.T) {
 
 // Update/Updates mengubah secara parsial
 func TestUpdateSelectedColumns(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	// Updates => mengubah beberapa kolom
 	// jika menggunakan map maka "" (string kosong) akan dianggap sebagai perubahan juga
 	err := db.Model(&User{}).Where("id = ?", "1").Updates(map[string]interface{}{
@@ -409,7 +476,8 @@ func TestUpdateSelectedColumns(t *testing.T) {
 	assert.Nil(t, err)
 
 	// jika menggunakan struct maka "" (string kosong) tidak dianggap sebagai perubahan
-	err = db.Where("id = ?", "1").Updates(User{
+	// gunakan pointer karena User memiliki hook
+	err = db.Where("id = ?", "1").Updates(&User{
 		Name: Name{
 			FirstName: "Eko",
 			LastName:  "Khannedy",
@@ -419,6 +487,9 @@ func TestUpdateSelectedColumns(t *testing.T) {
 }
 
 func TestAutoIncrement(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	for i := 0; i < 10; i++ {
 		userLog := UserLog{
 			UserId: "1",
@@ -434,6 +505,9 @@ func TestAutoIncrement(t *testing.T) {
 }
 
 func TestSaveOrUpdate(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	userLog := UserLog{
 		UserId: "1",
 		Action: "Test Action",
@@ -452,6 +526,9 @@ func TestSaveOrUpdate(t *testing.T) {
 }
 
 func TestSaveOrUpdateNonAutoIncrement(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{
 		ID: "99", // belum ada user dengan ID '99'
 		Name: Name{
@@ -468,6 +545,9 @@ func TestSaveOrUpdateNonAutoIncrement(t *testing.T) {
 }
 
 func TestConflict(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{
 		ID: "88",
 		Name: Name{
@@ -481,9 +561,18 @@ func TestConflict(t *testing.T) {
 }
 
 func TestDelete(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	err := db.Create(&[]User{
+		{ID: "88", Name: Name{FirstName: "User 88"}},
+		{ID: "99", Name: Name{FirstName: "User 99"}},
+	}).Error
+	assert.Nil(t, err)
+
 	var user User
 	// ambil data terlebih dahulu
-	err := db.Take(&user, "id = ?", "88").Error
+	err = db.Take(&user, "id = ?", "88").Error
 	assert.Nil(t, err)
 	// lalu hapus
 	err = db.Delete(&user).Error
@@ -498,6 +587,9 @@ func TestDelete(t *testing.T) {
 }
 
 func TestSoftDelete(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	todo := Todo{
 		UserId:      "1",
 		Title:       "Todo 1",
@@ -521,6 +613,9 @@ func TestSoftDelete(t *testing.T) {
 }
 
 func TestUnscoped(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var todo Todo
 	//  SELECT * FROM "todos" WHERE id = 1 ORDER BY "todos"."id" LIMIT 1
 	// method Unscoped menjadikan query yang dilakukan
@@ -540,6 +635,9 @@ func TestUnscoped(t *testing.T) {
 }
 
 func TestLock(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	err := db.Transaction(func(tx *gorm.DB) error {
 		var user User
 		// locking for UPDATE
@@ -558,9 +656,12 @@ func TestLock(t *testing.T) {
 }
 
 func TestCreateWallet(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	wallet := Wallet{
-		ID:      "1",
-		UserId:  "1",
+		ID:      "3",
+		UserId:  "3",
 		Balance: 1000000,
 	}
 
@@ -569,6 +670,9 @@ func TestCreateWallet(t *testing.T) {
 }
 
 func TestRetrieveRelation(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var user User
 	// method Preload => melakukan query terhadap relation
 	// Preload akan menjalan dua query, pertama ke tabel users lalu kedua ke tabel wallets
@@ -584,6 +688,9 @@ func TestRetrieveRelation(t *testing.T) {
 }
 
 func TestRetrieveRelationJoin(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var user User
 	// method Joins cocok untuk relasi one to one
 	// Join hanya melakukan satu query
@@ -597,6 +704,9 @@ func TestRetrieveRelationJoin(t *testing.T) {
 }
 
 func TestAutoCreateUpdate(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{
 		ID:       "20",
 		Password: "rahasia",
@@ -618,6 +728,9 @@ func TestAutoCreateUpdate(t *testing.T) {
 }
 
 func TestSkipAutoCreateUpdate(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{
 		ID:       "21",
 		Password: "rahasia",
@@ -638,6 +751,9 @@ func TestSkipAutoCreateUpdate(t *testing.T) {
 }
 
 func TestUserAndAddresses(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	user := User{
 		ID:       "2",
 		Password: "rahasia",
@@ -669,6 +785,9 @@ func TestUserAndAddresses(t *testing.T) {
 }
 
 func TestPreloadJoinOneToMany(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var users []User
 	// SELECT * FROM "addresses" WHERE "addresses"."user_id" IN ('1','20','2','10','7','6','8','4','11','99','12','3','5','21','14','13','9')
 	// SELECT "users"."id","users"."password","users"."first_name","users"."middle_name","users"."last_name","users"."created_at","users"."updated_at","Wallet"."id" AS "Wallet__id","Wallet"."user_id" AS "Wallet__user_id","Wallet"."balance" AS "Wallet__balance","Wallet"."created_at" AS "Wallet__created_at","Wallet"."updated_at" AS "Wallet__updated_at" FROM "users" LEFT JOIN "wallets" "Wallet" ON "users"."id" = "Wallet"."user_id"
@@ -677,6 +796,9 @@ func TestPreloadJoinOneToMany(t *testing.T) {
 }
 
 func TestTakePreloadJoinOneToMany(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	var user User
 	// di sini Preload digunakan untuk relasi one to many
 	// dan Joins digunakan untuk relasi one to one
@@ -686,6 +808,9 @@ func TestTakePreloadJoinOneToMany(t *testing.T) {
 }
 
 func TestBelongsTo(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	fmt.Println("Preload")
 	var addresses []Address
 	// SELECT * FROM "users" WHERE "users"."id" = '2'
@@ -702,6 +827,9 @@ func TestBelongsTo(t *testing.T) {
 }
 
 func TestBelongsToWallet(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
 	fmt.Println("Preload")
 	var wallets []Wallet
 	// SELECT * FROM "users" WHERE "users"."id" IN ('1','20','2')
@@ -0,0 +1,213 @@
+package belajargorm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	// bcrypt dengan cost bawaan terlalu lambat untuk fixture di setiap test
+	DefaultPasswordHasher = BcryptHasher{Cost: bcrypt.MinCost}
+	os.Exit(m.Run())
+}
+
+// newTestDB membuka database terisolasi untuk satu test, membangun schema
+// lalu mengisi fixture yang sudah diketahui isinya
+// sehingga test bisa berjalan dengan urutan apapun, paralel dan berulang kali
+//
+// driver dipilih lewat TEST_DB_DRIVER (bawaan sqlite) dan TEST_DB_DSN
+// - sqlite   => satu file database baru di t.TempDir()
+// - postgres => satu schema baru yang dihapus setelah test selesai
+// - mysql    => satu database baru yang dihapus setelah test selesai
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := migrateTestSchema(db); err != nil {
+		t.Fatalf("migrate test schema: %v", err)
+	}
+	if err := loadFixtures(db); err != nil {
+		t.Fatalf("load fixtures: %v", err)
+	}
+	return db
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Driver = os.Getenv("TEST_DB_DRIVER")
+	cfg.LogLevel = logger.Silent
+	if v := os.Getenv("TEST_DB_LOG_LEVEL"); v != "" {
+		level, err := ParseLogLevel(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.LogLevel = level
+	}
+
+	ctx := context.Background()
+	name := "test_" + randomSuffix(t)
+
+	switch cfg.Driver {
+	case "", "sqlite":
+		cfg.Driver = "sqlite"
+		cfg.Name = t.TempDir() + "/" + name + ".db"
+
+	case "postgres":
+		admin := openAdminDB(t, cfg)
+		if err := admin.Exec("CREATE SCHEMA " + name).Error; err != nil {
+			t.Fatalf("create schema: %v", err)
+		}
+		t.Cleanup(func() {
+			admin.Exec("DROP SCHEMA " + name + " CASCADE")
+		})
+		cfg.DSN = postgresWithSearchPath(os.Getenv("TEST_DB_DSN"), name)
+
+	case "mysql":
+		admin := openAdminDB(t, cfg)
+		if err := admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+			t.Fatalf("create database: %v", err)
+		}
+		t.Cleanup(func() {
+			admin.Exec("DROP DATABASE " + name)
+		})
+		mc, err := mysqldriver.ParseDSN(os.Getenv("TEST_DB_DSN"))
+		if err != nil {
+			t.Fatalf("parse TEST_DB_DSN: %v", err)
+		}
+		mc.DBName = name
+		cfg.DSN = mc.FormatDSN()
+
+	default:
+		t.Fatalf("unsupported TEST_DB_DRIVER %q", cfg.Driver)
+	}
+
+	db, err := Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// koneksi ke TEST_DB_DSN yang dipakai untuk membuat dan menghapus schema/database test
+func openAdminDB(t *testing.T, cfg Config) *gorm.DB {
+	t.Helper()
+
+	cfg.DSN = os.Getenv("TEST_DB_DSN")
+	if cfg.DSN == "" {
+		t.Fatalf("TEST_DB_DSN is required when TEST_DB_DRIVER=%s", cfg.Driver)
+	}
+	cfg.MaxOpenConns = 1
+	db, err := Open(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("open admin database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func postgresWithSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+func randomSuffix(t *testing.T) string {
+	t.Helper()
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// tabel sample hanya dipakai oleh test raw sql, dibuat oleh migration baseline
+type sampleRow struct {
+	ID   string `gorm:"primaryKey;column:id;size:100"`
+	Name string `gorm:"column:name;size:100"`
+}
+
+func (s *sampleRow) TableName() string {
+	return "sample"
+}
+
+func migrateTestSchema(db *gorm.DB) error {
+	_, err := NewMigrator(db).Apply(context.Background())
+	return err
+}
+
+// fixture
+// - sample  : 1 Eko, 2 Budi
+// - users   : 1 Eko Kurniawan Khannedy, 2 sampai 9 "User N", semua dengan password rahasia
+// - wallets : 1 milik user 1 dengan saldo 1000000
+// - todos   : 1 milik user 1 yang sudah di soft delete
+func loadFixtures(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		samples := []sampleRow{{ID: "1", Name: "Eko"}, {ID: "2", Name: "Budi"}}
+		if err := tx.Create(&samples).Error; err != nil {
+			return err
+		}
+
+		users := []User{{
+			ID:       "1",
+			Password: "rahasia",
+			Name: Name{
+				FirstName:  "Eko",
+				MiddleName: "Kurniawan",
+				LastName:   "Khannedy",
+			},
+		}}
+		for i := 2; i < 10; i++ {
+			users = append(users, User{
+				ID:       strconv.Itoa(i),
+				Password: "rahasia",
+				Name: Name{
+					FirstName: "User " + strconv.Itoa(i),
+				},
+			})
+		}
+		if err := tx.Omit("Wallet", "Addresses").Create(&users).Error; err != nil {
+			return err
+		}
+
+		wallet := Wallet{ID: "1", UserId: "1", Balance: 1000000}
+		if err := tx.Omit("User").Create(&wallet).Error; err != nil {
+			return err
+		}
+
+		todo := Todo{UserId: "1", Title: "Todo 1", Description: "Description 1"}
+		if err := tx.Create(&todo).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&todo).Error
+	})
+}
@@ -0,0 +1,204 @@
+package belajargorm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrIdempotencyKeyRequired = errors.New("belajargorm: idempotency key is required")
+	ErrIdempotencyConflict    = errors.New("belajargorm: idempotency key was used with a different request")
+)
+
+// DefaultIdempotencyTTL adalah lama sebuah idempotency key disimpan sebelum boleh dipakai ulang
+// ubah hanya saat inisialisasi aplikasi, bukan saat aplikasi sedang berjalan
+var DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyKey menyimpan hasil operasi yang sudah dijalankan dengan sebuah key
+// request yang sama dengan key yang sama akan mendapatkan Response ini tanpa menjalankan ulang operasinya
+type IdempotencyKey struct {
+	Key         string    `gorm:"primaryKey;column:idempotency_key;size:100"`
+	Operation   string    `gorm:"column:operation;size:100;not null"`
+	RequestHash string    `gorm:"column:request_hash;size:64;not null"`
+	Response    string    `gorm:"column:response;type:text"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+	ExpiresAt   time.Time `gorm:"column:expires_at;not null;index"`
+}
+
+func (k *IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// Idempotent menjalankan fn satu kali untuk setiap key
+// key disimpan di transaction yang sama dengan fn, sehingga jika fn gagal key tidak tersimpan
+// dan request boleh diulang. Pemanggilan berikutnya dengan key, operation dan request yang sama
+// mengembalikan hasil pertama (di-encode sebagai JSON) tanpa menjalankan fn lagi,
+// sedangkan operation atau request yang berbeda mengembalikan ErrIdempotencyConflict
+//
+// fn harus memakai tx yang diberikan, bukan db, agar perubahan dan key di-commit bersama
+func Idempotent[T any](ctx context.Context, db *gorm.DB, key, operation string, request interface{}, fn func(tx *gorm.DB) (T, error)) (T, error) {
+	var zero T
+	if key == "" {
+		return zero, ErrIdempotencyKeyRequired
+	}
+	hash, err := requestHash(operation, request)
+	if err != nil {
+		return zero, err
+	}
+
+	var result T
+	err = transaction(ctx, db, defaultMaxAttempts, func(tx *gorm.DB) error {
+		var replay T
+		replayed, err := claimIdempotencyKey(tx, key, operation, hash, &replay)
+		if err != nil {
+			return err
+		}
+		if replayed {
+			result = replay
+			return nil
+		}
+
+		value, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		response, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("belajargorm: encode idempotent response: %w", err)
+		}
+		err = tx.Model(&IdempotencyKey{}).Where("idempotency_key = ?", key).
+			Update("response", string(response)).Error
+		if err != nil {
+			return err
+		}
+		result = value
+		return nil
+	})
+	if err != nil {
+		return zero, err
+	}
+	return result, nil
+}
+
+// claimIdempotencyKey menyimpan key baru, atau membaca response milik key yang sudah ada ke dest
+// bernilai true jika response diambil dari key yang sudah ada
+// jika key yang sama sedang diproses transaction lain, insert akan menunggu transaction itu selesai
+func claimIdempotencyKey(tx *gorm.DB, key, operation, hash string, dest interface{}) (bool, error) {
+	now := time.Now().UTC()
+	row := IdempotencyKey{
+		Key:         key,
+		Operation:   operation,
+		RequestHash: hash,
+		ExpiresAt:   now.Add(DefaultIdempotencyTTL),
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return false, nil
+	}
+
+	var existing IdempotencyKey
+	if err := tx.Take(&existing, "idempotency_key = ?", key).Error; err != nil {
+		return false, err
+	}
+	if !existing.ExpiresAt.After(now) {
+		// key kadaluarsa dianggap belum pernah dipakai
+		if err := tx.Delete(&existing).Error; err != nil {
+			return false, err
+		}
+		return false, tx.Create(&row).Error
+	}
+	if existing.Operation != operation || existing.RequestHash != hash {
+		return false, fmt.Errorf("%w: %s", ErrIdempotencyConflict, key)
+	}
+	if err := json.Unmarshal([]byte(existing.Response), dest); err != nil {
+		return false, fmt.Errorf("belajargorm: decode idempotent response: %w", err)
+	}
+	return true, nil
+}
+
+func requestHash(operation string, request interface{}) (string, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("belajargorm: encode idempotent request: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(operation+"\n"), payload...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// PurgeIdempotencyKeys menghapus key yang sudah kadaluarsa dan mengembalikan jumlah yang dihapus
+func PurgeIdempotencyKeys(ctx context.Context, db *gorm.DB) (int64, error) {
+	result := db.WithContext(ctx).Where("expires_at <= ?", time.Now().UTC()).Delete(&IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// CreateWalletIdempotent membuat wallet satu kali untuk setiap key
+// jika key sudah pernah dipakai, wallet diisi dengan wallet yang dibuat sebelumnya
+// request dibandingkan sebelum ID dan timestamp diisi oleh gorm
+func CreateWalletIdempotent(ctx context.Context, db *gorm.DB, key string, wallet *Wallet) error {
+	request := *wallet
+	id, err := Idempotent(ctx, db, key, "wallet.create", request, func(tx *gorm.DB) (string, error) {
+		err := tx.Create(wallet).Error
+		return wallet.ID, err
+	})
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Take(wallet, "id = ?", id).Error
+}
+
+// CreateUserIdempotent membuat user satu kali untuk setiap key
+// password tidak ikut dibandingkan agar password asli tidak ikut di-hash ke idempotency_keys
+func CreateUserIdempotent(ctx context.Context, db *gorm.DB, key string, user *User) error {
+	request := *user
+	request.Password = ""
+	id, err := Idempotent(ctx, db, key, "user.create", request, func(tx *gorm.DB) (string, error) {
+		err := tx.Create(user).Error
+		return user.ID, err
+	})
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Take(user, "id = ?", id).Error
+}
+
+func (s *WalletService) idempotent(ctx context.Context, key, operation string, request interface{}, fn func(s *WalletService) error) error {
+	_, err := Idempotent(ctx, s.db, key, operation, request, func(tx *gorm.DB) (struct{}, error) {
+		return struct{}{}, fn(s.withDB(tx))
+	})
+	return err
+}
+
+// TransferIdempotent sama seperti Transfer, tetapi hanya dijalankan satu kali untuk setiap key
+func (s *WalletService) TransferIdempotent(ctx context.Context, key, fromWalletID, toWalletID string, amount int64) error {
+	request := map[string]interface{}{"from": fromWalletID, "to": toWalletID, "amount": amount}
+	return s.idempotent(ctx, key, "wallet.transfer", request, func(s *WalletService) error {
+		return s.Transfer(ctx, fromWalletID, toWalletID, amount)
+	})
+}
+
+// DepositIdempotent sama seperti Deposit, tetapi hanya dijalankan satu kali untuk setiap key
+func (s *WalletService) DepositIdempotent(ctx context.Context, key, walletID string, amount int64, reference string) error {
+	request := map[string]interface{}{"wallet": walletID, "amount": amount, "reference": reference}
+	return s.idempotent(ctx, key, "wallet.deposit", request, func(s *WalletService) error {
+		return s.Deposit(ctx, walletID, amount, reference)
+	})
+}
+
+// WithdrawIdempotent sama seperti Withdraw, tetapi hanya dijalankan satu kali untuk setiap key
+func (s *WalletService) WithdrawIdempotent(ctx context.Context, key, walletID string, amount int64, reference string) error {
+	request := map[string]interface{}{"wallet": walletID, "amount": amount, "reference": reference}
+	return s.idempotent(ctx, key, "wallet.withdraw", request, func(s *WalletService) error {
+		return s.Withdraw(ctx, walletID, amount, reference)
+	})
+}
@@ -0,0 +1,159 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestIdempotentTransfer(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 0)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	assert.Nil(t, service.TransferIdempotent(ctx, "transfer-1", "1", "2", 1000))
+	assert.Nil(t, service.TransferIdempotent(ctx, "transfer-1", "1", "2", 1000))
+	assert.Equal(t, int64(999000), walletBalance(t, db, "1"))
+	assert.Equal(t, int64(1000), walletBalance(t, db, "2"))
+
+	// key yang sama dengan request atau operation berbeda ditolak
+	assert.ErrorIs(t, service.TransferIdempotent(ctx, "transfer-1", "1", "2", 2000), ErrIdempotencyConflict)
+	assert.ErrorIs(t, service.DepositIdempotent(ctx, "transfer-1", "1", 1000, "topup"), ErrIdempotencyConflict)
+	assert.ErrorIs(t, service.TransferIdempotent(ctx, "", "1", "2", 1000), ErrIdempotencyKeyRequired)
+
+	assert.Equal(t, int64(999000), walletBalance(t, db, "1"))
+	assertLedgerConsistent(t, db)
+}
+
+func TestIdempotentFailureIsNotStored(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 0)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	// transfer gagal tidak menyimpan key, sehingga boleh diulang setelah saldo cukup
+	assert.ErrorIs(t, service.WithdrawIdempotent(ctx, "withdraw-1", "2", 500, "tarik"), ErrInsufficientBalance)
+	assert.Nil(t, service.Deposit(ctx, "2", 500, "topup"))
+	assert.Nil(t, service.WithdrawIdempotent(ctx, "withdraw-1", "2", 500, "tarik"))
+	assert.Nil(t, service.WithdrawIdempotent(ctx, "withdraw-1", "2", 500, "tarik"))
+	assert.Equal(t, int64(0), walletBalance(t, db, "2"))
+}
+
+func TestIdempotentConcurrent(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 0)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, service.TransferIdempotent(ctx, "transfer-concurrent", "1", "2", 100))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), walletBalance(t, db, "2"))
+}
+
+func TestIdempotentResponse(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(tx *gorm.DB) (int64, error) {
+		calls++
+		var count int64
+		err := tx.Model(&User{}).Count(&count).Error
+		return count, err
+	}
+	first, err := Idempotent(ctx, db, "count-1", "user.count", nil, fn)
+	assert.Nil(t, err)
+	assert.Nil(t, db.Create(&User{ID: "50", Password: "rahasia", Name: Name{FirstName: "User 50"}}).Error)
+
+	second, err := Idempotent(ctx, db, "count-1", "user.count", nil, fn)
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, calls)
+
+	// fn yang gagal mengembalikan error-nya apa adanya
+	_, err = Idempotent(ctx, db, "count-2", "user.count", nil, func(tx *gorm.DB) (int64, error) {
+		return 0, errors.New("gagal")
+	})
+	assert.EqualError(t, err, "gagal")
+}
+
+func TestIdempotentExpiry(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 0)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	assert.Nil(t, service.DepositIdempotent(ctx, "deposit-1", "2", 100, "topup"))
+	assert.Nil(t, service.DepositIdempotent(ctx, "deposit-2", "2", 100, "topup"))
+	err := db.Model(&IdempotencyKey{}).Where("idempotency_key = ?", "deposit-1").
+		Update("expires_at", time.Now().UTC().Add(-time.Minute)).Error
+	assert.Nil(t, err)
+
+	// key kadaluarsa boleh dipakai ulang, bahkan dengan request berbeda
+	assert.Nil(t, service.DepositIdempotent(ctx, "deposit-1", "2", 300, "topup"))
+	assert.Equal(t, int64(500), walletBalance(t, db, "2"))
+
+	err = db.Model(&IdempotencyKey{}).Where("idempotency_key = ?", "deposit-2").
+		Update("expires_at", time.Now().UTC().Add(-time.Minute)).Error
+	assert.Nil(t, err)
+	purged, err := PurgeIdempotencyKeys(ctx, db)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	var count int64
+	assert.Nil(t, db.Model(&IdempotencyKey{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestCreateIdempotent(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	first := Wallet{UserId: "2", Balance: 5000}
+	assert.Nil(t, CreateWalletIdempotent(ctx, db, "wallet-2", &first))
+	retry := Wallet{UserId: "2", Balance: 5000}
+	assert.Nil(t, CreateWalletIdempotent(ctx, db, "wallet-2", &retry))
+	assert.Equal(t, first.ID, retry.ID)
+	assert.Equal(t, int64(5000), retry.Balance)
+	other := Wallet{UserId: "3", Balance: 5000}
+	assert.ErrorIs(t, CreateWalletIdempotent(ctx, db, "wallet-2", &other), ErrIdempotencyConflict)
+
+	var count int64
+	assert.Nil(t, db.Model(&Wallet{}).Where("user_id = ?", "2").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	newUser := func() *User {
+		return &User{Password: "rahasia", Name: Name{FirstName: "Joko"}}
+	}
+	user := newUser()
+	assert.Nil(t, CreateUserIdempotent(ctx, db, "user-joko", user))
+	replay := newUser()
+	assert.Nil(t, CreateUserIdempotent(ctx, db, "user-joko", replay))
+	assert.Equal(t, user.ID, replay.ID)
+	assert.True(t, replay.VerifyPassword("rahasia"))
+
+	var response string
+	err := db.Model(&IdempotencyKey{}).Select("response").Where("idempotency_key = ?", "user-joko").Scan(&response).Error
+	assert.Nil(t, err)
+	assert.NotContains(t, response, "rahasia")
+	assertLedgerConsistent(t, db)
+}
@@ -0,0 +1,82 @@
+package belajargorm
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"github.com/segmentio/ksuid"
+)
+
+// IDGenerator membuat primary key berupa string untuk model seperti User dan Wallet
+// ID yang dihasilkan harus terurut berdasarkan waktu pembuatan jika diurutkan sebagai string,
+// sehingga First/Last dan Order("id") mengikuti urutan data dibuat
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+// DefaultIDGenerator dipakai oleh hook BeforeCreate jika ID masih kosong
+// ubah hanya saat inisialisasi aplikasi, bukan saat aplikasi sedang berjalan
+var DefaultIDGenerator IDGenerator = UUIDv7Generator{}
+
+// UUIDv7Generator membuat UUID versi 7 (RFC 9562), contoh 01907a5e-3f1c-7c2a-9b1e-2f6d8c4a1b30
+// urutan dijamin monoton di dalam satu proses
+type UUIDv7Generator struct{}
+
+func (UUIDv7Generator) NewID() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// ULIDGenerator membuat ULID, contoh 01J1XA2S8Q4V7K3W9Z6YBN0C5D
+// ID yang dibuat di milidetik yang sama tetap terurut karena memakai entropy monoton
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	entropy *ulid.MonotonicEntropy
+}
+
+func (g *ULIDGenerator) NewID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.entropy == nil {
+		g.entropy = ulid.Monotonic(rand.Reader, 0)
+	}
+	id, err := ulid.New(ulid.Timestamp(time.Now()), g.entropy)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// KSUIDGenerator membuat KSUID, contoh 2iK3bQeN2d0Jv9pXl6Yh1uCwZtA
+// timestamp KSUID hanya sampai detik, jadi ID yang dibuat di detik yang sama
+// memakai payload berikutnya dari ID sebelumnya agar tetap terurut
+type KSUIDGenerator struct {
+	mu   sync.Mutex
+	last ksuid.KSUID
+}
+
+func (g *KSUIDGenerator) NewID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id, err := ksuid.NewRandomWithTime(time.Now())
+	if err != nil {
+		return "", err
+	}
+	if !g.last.IsNil() && ksuid.Compare(id, g.last) <= 0 {
+		id = g.last.Next()
+	}
+	g.last = id
+	return id.String(), nil
+}
+
+// NewID membuat ID baru memakai DefaultIDGenerator
+func NewID() (string, error) {
+	return DefaultIDGenerator.NewID()
+}
@@ -0,0 +1,61 @@
+package belajargorm
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDGeneratorsSortByCreation(t *testing.T) {
+	generators := map[string]IDGenerator{
+		"uuidv7": UUIDv7Generator{},
+		"ulid":   &ULIDGenerator{},
+		"ksuid":  &KSUIDGenerator{},
+	}
+	for name, generator := range generators {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ids := make([]string, 1000)
+			seen := map[string]bool{}
+			for i := range ids {
+				id, err := generator.NewID()
+				assert.Nil(t, err)
+				assert.False(t, seen[id], "duplicate id %s", id)
+				seen[id] = true
+				ids[i] = id
+			}
+			assert.True(t, sort.StringsAreSorted(ids))
+		})
+	}
+}
+
+func TestGeneratedIDOnCreate(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	var created []string
+	for i := 0; i < 5; i++ {
+		user := User{
+			Password: "rahasia",
+			Name:     Name{FirstName: "Generated"},
+			Wallet:   Wallet{Balance: 1000},
+		}
+		err := db.Create(&user).Error
+		assert.Nil(t, err)
+		assert.NotEqual(t, "", user.ID)
+		assert.NotEqual(t, "", user.Wallet.ID)
+		assert.Equal(t, user.ID, user.Wallet.UserId)
+		created = append(created, user.ID)
+	}
+
+	var users []User
+	err := db.Where("first_name = ?", "Generated").Order("id").Find(&users).Error
+	assert.Nil(t, err)
+	var ordered []string
+	for _, user := range users {
+		ordered = append(ordered, user.ID)
+	}
+	assert.Equal(t, created, ordered)
+}
@@ -0,0 +1,262 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EntryDirection adalah sisi pencatatan WalletEntry
+// credit menambah saldo akun, debit mengurangi saldo akun
+type EntryDirection string
+
+const (
+	Debit  EntryDirection = "debit"
+	Credit EntryDirection = "credit"
+)
+
+// akun selain wallet diawali dengan "@", saldo akun ini tidak disimpan di tabel wallets
+const (
+	// AccountExternal adalah lawan transaksi untuk uang yang masuk atau keluar dari sistem
+	AccountExternal = "@external"
+	// AccountFX menampung selisih mata uang pada transfer antar mata uang
+	AccountFX = "@fx"
+)
+
+var ErrUnbalancedEntries = errors.New("belajargorm: ledger entries do not balance")
+
+// WalletEntry adalah satu baris di ledger double-entry
+// setiap perubahan saldo dicatat sebagai beberapa entry dengan GroupId yang sama
+// dan total debit harus sama dengan total credit untuk setiap mata uang di group tersebut
+type WalletEntry struct {
+	ID        string         `gorm:"primaryKey;column:id;size:100"`
+	GroupId   string         `gorm:"column:group_id;size:100;not null;index"`
+	Account   string         `gorm:"column:account;size:100;not null;index"`
+	Direction EntryDirection `gorm:"column:direction;size:10;not null"`
+	Amount    int64          `gorm:"column:amount;not null"`
+	Currency  string         `gorm:"column:currency;size:3;not null"`
+	Reference string         `gorm:"column:reference;size:100"`
+	CreatedAt time.Time      `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (e *WalletEntry) TableName() string {
+	return "wallet_entries"
+}
+
+// signed mengembalikan pengaruh entry terhadap saldo akun
+func (e WalletEntry) signed() int64 {
+	if e.Direction == Debit {
+		return -e.Amount
+	}
+	return e.Amount
+}
+
+func isSystemAccount(account string) bool {
+	return strings.HasPrefix(account, "@")
+}
+
+// transferEntries membuat pasangan entry untuk memindahkan amount dari akun from ke akun to
+func transferEntries(from, to string, amount Money) []WalletEntry {
+	return []WalletEntry{
+		{Account: from, Direction: Debit, Amount: amount.Amount, Currency: amount.Currency},
+		{Account: to, Direction: Credit, Amount: amount.Amount, Currency: amount.Currency},
+	}
+}
+
+// insertEntries memvalidasi lalu menyimpan entry dalam satu group tanpa mengubah saldo wallets
+func insertEntries(tx *gorm.DB, reference string, entries []WalletEntry) (string, error) {
+	if len(entries) < 2 {
+		return "", fmt.Errorf("%w: a group needs at least two entries", ErrUnbalancedEntries)
+	}
+	totals := map[string]int64{}
+	for _, entry := range entries {
+		if entry.Amount <= 0 {
+			return "", fmt.Errorf("%w: %d", ErrInvalidAmount, entry.Amount)
+		}
+		if entry.Direction != Debit && entry.Direction != Credit {
+			return "", fmt.Errorf("belajargorm: invalid entry direction %q", entry.Direction)
+		}
+		if _, err := CurrencyExponent(entry.Currency); err != nil {
+			return "", err
+		}
+		totals[entry.Currency] += entry.signed()
+	}
+	for currency, total := range totals {
+		if total != 0 {
+			return "", fmt.Errorf("%w: credit minus debit in %s is %d", ErrUnbalancedEntries, currency, total)
+		}
+	}
+
+	groupID, err := NewID()
+	if err != nil {
+		return "", err
+	}
+	// created_at disimpan dalam UTC agar bisa dibandingkan dengan periode Statement di semua dialect
+	now := time.Now().UTC()
+	rows := make([]WalletEntry, len(entries))
+	for i, entry := range entries {
+		id, err := NewID()
+		if err != nil {
+			return "", err
+		}
+		entry.ID = id
+		entry.GroupId = groupID
+		entry.CreatedAt = now
+		if entry.Reference == "" {
+			entry.Reference = reference
+		}
+		rows[i] = entry
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return "", err
+	}
+	return groupID, nil
+}
+
+// postEntries menyimpan entry lalu memperbarui saldo (proyeksi) setiap wallet yang terlibat
+// pemanggil bertanggung jawab mengunci wallet dan memeriksa saldo sebelum posting
+func postEntries(tx *gorm.DB, reference string, entries []WalletEntry) (string, error) {
+	groupID, err := insertEntries(tx, reference, entries)
+	if err != nil {
+		return "", err
+	}
+
+	deltas := map[string]int64{}
+	currencies := map[string]string{}
+	var accounts []string
+	for _, entry := range entries {
+		if isSystemAccount(entry.Account) {
+			continue
+		}
+		if currency, ok := currencies[entry.Account]; !ok {
+			accounts = append(accounts, entry.Account)
+			currencies[entry.Account] = entry.Currency
+		} else if currency != entry.Currency {
+			return "", fmt.Errorf("%w: wallet %s has entries in %s and %s", ErrCurrencyMismatch, entry.Account, currency, entry.Currency)
+		}
+		deltas[entry.Account] += entry.signed()
+	}
+	for _, account := range accounts {
+		updated, err := setWalletBalance(tx, account, currencies[account], gorm.Expr("balance + ?", deltas[account]))
+		if err != nil {
+			return "", err
+		}
+		if !updated {
+			return "", fmt.Errorf("%w: %s in %s", ErrWalletNotFound, account, currencies[account])
+		}
+	}
+	return groupID, nil
+}
+
+// setWalletBalance menulis kolom balance yang tidak bisa diubah lewat model Wallet (<-:create)
+// karena itu memakai Table, bukan Model, updated_at diisi sendiri dan perubahannya dicatat dengan writeWalletLog
+// jika currency tidak kosong, wallet hanya diubah jika mata uangnya sama
+func setWalletBalance(tx *gorm.DB, walletID, currency string, balance interface{}) (bool, error) {
+	wallet := func() *gorm.DB {
+		query := tx.Table("wallets").Where("id = ?", walletID)
+		if currency != "" {
+			query = query.Where("currency = ?", currency)
+		}
+		return query
+	}
+
+	var before, after struct {
+		UserId  string
+		Balance int64
+	}
+	err := wallet().Select("user_id", "balance").Take(&before).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	err = wallet().Updates(map[string]interface{}{
+		"balance":    balance,
+		"updated_at": tx.NowFunc(),
+	}).Error
+	if err != nil {
+		return false, err
+	}
+	if err := wallet().Select("user_id", "balance").Take(&after).Error; err != nil {
+		return false, err
+	}
+	if before.Balance == after.Balance {
+		return true, nil
+	}
+	changes := map[string]AuditChange{"balance": {Old: before.Balance, New: after.Balance}}
+	return true, writeWalletLog(tx, walletID, before.UserId, changes)
+}
+
+// LedgerBalance menghitung saldo akun langsung dari wallet_entries
+// wallet hanya punya satu mata uang, tetapi akun sistem seperti AccountExternal bisa berisi
+// beberapa mata uang, untuk akun seperti itu pakai LedgerBalanceIn
+func LedgerBalance(ctx context.Context, db *gorm.DB, account string) (int64, error) {
+	return ledgerBalance(db.WithContext(ctx).Where("account = ?", account))
+}
+
+// LedgerBalanceIn menghitung saldo akun untuk satu mata uang dari wallet_entries
+func LedgerBalanceIn(ctx context.Context, db *gorm.DB, account, currency string) (Money, error) {
+	balance, err := ledgerBalance(db.WithContext(ctx).Where("account = ? AND currency = ?", account, currency))
+	return Money{Amount: balance, Currency: currency}, err
+}
+
+func ledgerBalance(query *gorm.DB) (int64, error) {
+	var balance int64
+	err := query.Model(&WalletEntry{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END), 0)", Credit).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// UnbalancedGroups mengembalikan GroupId yang total debit dan credit-nya tidak sama
+// untuk salah satu mata uang, pada ledger yang sehat hasilnya selalu kosong
+func UnbalancedGroups(ctx context.Context, db *gorm.DB) ([]string, error) {
+	var groups []string
+	err := db.WithContext(ctx).Model(&WalletEntry{}).
+		Distinct("group_id").
+		Group("group_id, currency").
+		Having("SUM(CASE WHEN direction = ? THEN amount ELSE -amount END) <> 0", Credit).
+		Order("group_id").
+		Scan(&groups).Error
+	return groups, err
+}
+
+// RebuildBalances menghitung ulang kolom wallets.balance dari wallet_entries
+// jika walletIDs kosong maka semua wallet dihitung ulang
+// koreksi di sini tidak dicatat, pakai WalletService.Reconcile jika koreksi perlu diaudit
+func RebuildBalances(ctx context.Context, db *gorm.DB, walletIDs ...string) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&Wallet{}).Order("id")
+		if len(walletIDs) > 0 {
+			query = query.Where("id IN ?", walletIDs)
+		}
+		var ids []string
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		// wallet dikunci sebelum ledger dijumlahkan, agar Transfer yang commit di antaranya tidak tertimpa
+		wallets, err := lockWallets(tx, ids...)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			balance, err := LedgerBalance(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if wallets[id].Balance == balance {
+				continue
+			}
+			if _, err := setWalletBalance(tx, id, "", balance); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,174 @@
+package belajargorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// assertLedgerConsistent memastikan setiap group seimbang dan saldo wallets sama dengan ledger
+func assertLedgerConsistent(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	ctx := context.Background()
+
+	groups, err := UnbalancedGroups(ctx, db)
+	assert.Nil(t, err)
+	assert.Empty(t, groups)
+
+	var wallets []Wallet
+	assert.Nil(t, db.Find(&wallets).Error)
+	for _, wallet := range wallets {
+		balance, err := LedgerBalance(ctx, db, wallet.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, balance, wallet.Balance, wallet.ID)
+	}
+}
+
+func TestLedgerOpeningBalance(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	var entries []WalletEntry
+	err := db.Where("account IN ?", []string{"1", AccountExternal}).Order("direction").Find(&entries).Error
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, entries[0].GroupId, entries[1].GroupId)
+	assert.Equal(t, Credit, entries[0].Direction)
+	assert.Equal(t, "1", entries[0].Account)
+	assert.Equal(t, "opening balance", entries[0].Reference)
+
+	// Save user dengan wallet yang sudah ada tidak mencatat saldo awal dua kali
+	var user User
+	assert.Nil(t, db.Preload("Wallet").Take(&user, "id = ?", "1").Error)
+	assert.Nil(t, db.Save(&user).Error)
+
+	assertLedgerConsistent(t, db)
+}
+
+func TestLedgerUpsertWalletWithoutEntries(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	// wallet lama tanpa entry, misalnya dibuat langsung di database
+	now := time.Now()
+	err := db.Table("wallets").Create(map[string]interface{}{
+		"id": "50", "user_id": "2", "balance": 0, "currency": "IDR", "status": "active",
+		"is_default": false, "created_at": now, "updated_at": now,
+	}).Error
+	assert.Nil(t, err)
+
+	// upsert tidak mengubah balance, jadi saldo awal tidak boleh dicatat
+	var user User
+	assert.Nil(t, db.Take(&user, "id = ?", "2").Error)
+	user.Wallets = []Wallet{{ID: "50", UserId: "2", Balance: 5000}}
+	assert.Nil(t, db.Save(&user).Error)
+
+	assert.Equal(t, int64(0), walletBalance(t, db, "50"))
+	var count int64
+	assert.Nil(t, db.Model(&WalletEntry{}).Where("account = ?", "50").Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+	assertLedgerConsistent(t, db)
+}
+
+func TestLedgerBalanceIsReadOnly(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	var wallet Wallet
+	assert.Nil(t, db.Take(&wallet, "id = ?", "1").Error)
+	wallet.Balance = 5
+	assert.Nil(t, db.Save(&wallet).Error)
+	assert.Nil(t, db.Model(&wallet).Updates(&Wallet{Balance: 7}).Error)
+
+	assert.Equal(t, int64(1000000), walletBalance(t, db, "1"))
+	assertLedgerConsistent(t, db)
+}
+
+func TestLedgerDepositWithdraw(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	assert.Nil(t, service.Deposit(ctx, "1", 500, "topup"))
+	assert.Nil(t, service.Withdraw(ctx, "1", 200, "tarik tunai"))
+	assert.ErrorIs(t, service.Withdraw(ctx, "1", 2000000, "tarik tunai"), ErrInsufficientBalance)
+	assert.ErrorIs(t, service.Deposit(ctx, "404", 500, "topup"), ErrWalletNotFound)
+	assert.Equal(t, int64(1000300), walletBalance(t, db, "1"))
+
+	var count int64
+	assert.Nil(t, db.Model(&WalletEntry{}).Where("reference = ?", "topup").Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	external, err := LedgerBalance(ctx, db, AccountExternal)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1000300), external)
+	assertLedgerConsistent(t, db)
+}
+
+func TestLedgerUnbalanced(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := postEntries(tx, "salah", []WalletEntry{
+			{Account: "1", Direction: Debit, Amount: 100, Currency: "IDR"},
+			{Account: AccountExternal, Direction: Credit, Amount: 90, Currency: "IDR"},
+		})
+		return err
+	})
+	assert.ErrorIs(t, err, ErrUnbalancedEntries)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		_, err := postEntries(tx, "salah", []WalletEntry{{Account: "1", Direction: Credit, Amount: 100, Currency: "IDR"}})
+		return err
+	})
+	assert.ErrorIs(t, err, ErrUnbalancedEntries)
+
+	// jumlah yang sama dalam mata uang berbeda tidak seimbang
+	err = db.Transaction(func(tx *gorm.DB) error {
+		_, err := postEntries(tx, "salah", []WalletEntry{
+			{Account: "1", Direction: Debit, Amount: 100, Currency: "IDR"},
+			{Account: AccountExternal, Direction: Credit, Amount: 100, Currency: "USD"},
+		})
+		return err
+	})
+	assert.ErrorIs(t, err, ErrUnbalancedEntries)
+	assertLedgerConsistent(t, db)
+}
+
+func TestRebuildBalances(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	// saldo yang diubah langsung di database dikembalikan sesuai ledger
+	err := db.Model(&Wallet{}).Where("id = ?", "1").UpdateColumn("balance", 42).Error
+	assert.Nil(t, err)
+	assert.Nil(t, RebuildBalances(ctx, db))
+	assert.Equal(t, int64(1000000), walletBalance(t, db, "1"))
+}
+
+func TestLedgerMigrationBackfill(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	// kembali ke schema sebelum ledger, saldo wallet 1 belum punya entry
+	for {
+		done, err := migrator.Rollback(ctx, 1)
+		assert.Nil(t, err)
+		if len(done) == 0 || done[0].Version == 3 {
+			break
+		}
+	}
+	assert.False(t, db.Migrator().HasTable(&WalletEntry{}))
+
+	_, err := migrator.Apply(ctx)
+	assert.Nil(t, err)
+	assertLedgerConsistent(t, db)
+}
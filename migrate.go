@@ -0,0 +1,329 @@
+package belajargorm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migration adalah satu langkah perubahan schema
+// migration dijalankan berurutan berdasarkan Version dan masing-masing di dalam transaction
+//
+// Up dan Down sebaiknya memakai struct snapshot milik migration itu sendiri
+// bukan model yang sekarang, karena model akan terus berubah
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration mencatat migration yang sudah dijalankan
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;column:version;autoIncrement:false"`
+	Name      string    `gorm:"column:name;size:255;not null"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null"`
+}
+
+func (m *SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus adalah status satu migration, hasil dari Migrator.Status
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+var (
+	migrationsMu sync.Mutex
+	migrations   = map[int64]Migration{}
+)
+
+// registerMigration dipanggil dari init() di setiap file migration_xxx.go
+func registerMigration(m Migration) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	if m.Up == nil {
+		panic(fmt.Sprintf("belajargorm: migration %d has no Up", m.Version))
+	}
+	if dup, ok := migrations[m.Version]; ok {
+		panic(fmt.Sprintf("belajargorm: migration %d registered twice (%s, %s)", m.Version, dup.Name, m.Name))
+	}
+	migrations[m.Version] = m
+}
+
+// Migrations mengembalikan semua migration yang terdaftar, terurut berdasarkan Version
+func Migrations() []Migration {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	result := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		result = append(result, m)
+	}
+	sortMigrations(result)
+	return result
+}
+
+func sortMigrations(ms []Migration) {
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Version < ms[j].Version
+	})
+}
+
+// Migrator menjalankan apply, rollback, status dan redo
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator membuat Migrator untuk migration yang diberikan
+// jika tidak ada yang diberikan maka memakai semua migration yang terdaftar
+func NewMigrator(db *gorm.DB, ms ...Migration) *Migrator {
+	if len(ms) == 0 {
+		ms = Migrations()
+	} else {
+		ms = append([]Migration(nil), ms...)
+		sortMigrations(ms)
+	}
+	return &Migrator{db: db, migrations: ms}
+}
+
+func (m *Migrator) ensureTable(db *gorm.DB) error {
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil
+	}
+	return db.Migrator().CreateTable(&SchemaMigration{})
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	if err := m.ensureTable(db); err != nil {
+		return nil, fmt.Errorf("belajargorm: create schema_migrations: %w", err)
+	}
+	var rows []SchemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("belajargorm: read schema_migrations: %w", err)
+	}
+	result := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// Apply menjalankan semua migration yang belum dijalankan secara berurutan
+// dan mengembalikan migration yang berhasil dijalankan
+func (m *Migrator) Apply(ctx context.Context) ([]Migration, error) {
+	db := m.db.WithContext(ctx)
+	applied, err := m.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.up(db, migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Rollback membatalkan migration terakhir sebanyak steps (minimal 1)
+// dan mengembalikan migration yang berhasil dibatalkan
+func (m *Migrator) Rollback(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("belajargorm: rollback steps must be at least 1, got %d", steps)
+	}
+	db := m.db.WithContext(ctx)
+	applied, err := m.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if steps < len(versions) {
+		versions = versions[:steps]
+	}
+
+	var done []Migration
+	for _, version := range versions {
+		migration, ok := m.find(version)
+		if !ok {
+			return done, fmt.Errorf("belajargorm: migration %d (%s) is applied but unknown", version, applied[version].Name)
+		}
+		if err := m.down(db, migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Redo membatalkan migration terakhir lalu menjalankannya kembali
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	done, err := m.Rollback(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(done) == 0 {
+		return nil, nil
+	}
+	migration := done[0]
+	if err := m.up(m.db.WithContext(ctx), migration); err != nil {
+		return nil, err
+	}
+	return &migration, nil
+}
+
+// Status mengembalikan status semua migration
+// migration yang tercatat di database tapi tidak dikenal tetap ditampilkan
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			delete(applied, migration.Version)
+		}
+		result = append(result, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		result = append(result, MigrationStatus{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: &appliedAt})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) up(db *gorm.DB, migration Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("belajargorm: apply migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) down(db *gorm.DB, migration Migration) error {
+	if migration.Down == nil {
+		return fmt.Errorf("belajargorm: migration %d %s is irreversible", migration.Version, migration.Name)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("belajargorm: rollback migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// createPartialUniqueIndex membuat unique index dengan kondisi where dari tag model, contoh
+//
+//	UserId string `gorm:"uniqueIndex:idx_addresses_primary,where:is_primary"`
+//
+// mysql tidak mendukung partial index, sehingga dibuat functional index yang setiap kolomnya
+// bernilai NULL jika kondisi tidak terpenuhi (NULL tidak dianggap duplikat)
+// dipakai mulai migration 13, migration yang lebih lama tetap memakai kodenya sendiri
+func createPartialUniqueIndex(tx *gorm.DB, model interface{}, name string) error {
+	if tx.Dialector.Name() != "mysql" {
+		return tx.Migrator().CreateIndex(model, name)
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	idx := stmt.Schema.LookIndex(name)
+	if idx == nil || idx.Where == "" {
+		return fmt.Errorf("belajargorm: %s has no partial index %s", stmt.Schema.Name, name)
+	}
+	parts := make([]string, len(idx.Fields))
+	args := []interface{}{clause.Column{Name: name}, clause.Table{Name: stmt.Schema.Table}}
+	for i, field := range idx.Fields {
+		parts[i] = "(CASE WHEN " + idx.Where + " THEN ? END)"
+		args = append(args, clause.Column{Name: field.DBName})
+	}
+	return tx.Exec("CREATE UNIQUE INDEX ? ON ? ("+strings.Join(parts, ", ")+")", args...).Error
+}
+
+// restoreIndexes membuat ulang index model snapshot yang hilang setelah DropColumn,
+// karena DropColumn di sqlite membuat ulang tabel tanpa index (dialect lain tidak terpengaruh)
+// models adalah snapshot yang index-nya masih berlaku setelah rollback, contoh
+//
+//	restoreIndexes(tx, &baselineAddress{}, &addressRole{})
+func restoreIndexes(tx *gorm.DB, models ...interface{}) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return nil
+	}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		indexes := stmt.Schema.ParseIndexes()
+		names := make([]string, 0, len(indexes))
+		for name := range indexes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if tx.Migrator().HasIndex(model, name) {
+				continue
+			}
+			var err error
+			if indexes[name].Where != "" {
+				err = createPartialUniqueIndex(tx, model, name)
+			} else {
+				err = tx.Migrator().CreateIndex(model, name)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
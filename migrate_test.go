@@ -0,0 +1,126 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestMigrateRollbackAll(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	// newTestDB sudah menjalankan semua migration
+	done, err := migrator.Apply(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(done))
+
+	statuses, err := migrator.Status(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, len(Migrations()), len(statuses))
+	for _, s := range statuses {
+		assert.True(t, s.Applied, s.Name)
+	}
+
+	// steps kurang dari 1 ditolak tanpa membatalkan migration apapun
+	for _, steps := range []int{0, -1} {
+		done, err = migrator.Rollback(ctx, steps)
+		assert.NotNil(t, err, steps)
+		assert.Empty(t, done)
+	}
+	assert.True(t, db.Migrator().HasTable(&User{}))
+
+	done, err = migrator.Rollback(ctx, len(Migrations()))
+	assert.Nil(t, err)
+	assert.Equal(t, len(Migrations()), len(done))
+	assert.False(t, db.Migrator().HasTable(&User{}))
+
+	done, err = migrator.Apply(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, len(Migrations()), len(done))
+	assert.True(t, db.Migrator().HasTable(&User{}))
+}
+
+func TestMigrateRedoAndFailure(t *testing.T) {
+	t.Parallel()
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	type note struct {
+		ID   int64
+		Body string
+	}
+	createNotes := Migration{
+		Version: 1,
+		Name:    "create notes",
+		Up:      func(tx *gorm.DB) error { return tx.Migrator().CreateTable(&note{}) },
+		Down:    func(tx *gorm.DB) error { return tx.Migrator().DropTable(&note{}) },
+	}
+	broken := Migration{
+		Version: 2,
+		Name:    "broken",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&note{}, "Body"); err != nil {
+				return err
+			}
+			return errors.New("gagal")
+		},
+	}
+
+	migrator := NewMigrator(db, broken, createNotes)
+	done, err := migrator.Apply(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(done))
+	assert.Equal(t, "create notes", done[0].Name)
+
+	statuses, err := migrator.Status(ctx)
+	assert.Nil(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	redone, err := migrator.Redo(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), redone.Version)
+	assert.True(t, db.Migrator().HasTable(&note{}))
+
+	// migration tanpa Down tidak bisa di rollback
+	_, err = NewMigrator(db, Migration{Version: 1, Name: "create notes", Up: createNotes.Up}).Rollback(ctx, 1)
+	assert.NotNil(t, err)
+}
+
+func TestMigrateRollbackRestoresIndexes(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	// 16 address versions, 15 address coordinates
+	done, err := migrator.Rollback(ctx, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{16, 15}, []int64{done[0].Version, done[1].Version})
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_user_id"))
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_primary"))
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_fingerprint"))
+
+	// 14 address fingerprint
+	_, err = migrator.Rollback(ctx, 1)
+	assert.Nil(t, err)
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_user_id"))
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_primary"))
+
+	// 13 address roles, 12 structured address
+	_, err = migrator.Rollback(ctx, 2)
+	assert.Nil(t, err)
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_user_id"))
+	assert.False(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_primary"))
+
+	_, err = migrator.Apply(ctx)
+	assert.Nil(t, err)
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_location"))
+	assert.True(t, db.Migrator().HasIndex(&Address{}, "idx_addresses_primary"))
+}
@@ -0,0 +1,101 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// baseline menggantikan database.sql
+// schema disesuaikan dengan mapping model User, UserLog, Todo, Wallet dan Address saat ini
+
+type baselineSample struct {
+	ID   string `gorm:"primaryKey;size:100"`
+	Name string `gorm:"size:100;not null"`
+}
+
+func (baselineSample) TableName() string { return "sample" }
+
+type baselineUser struct {
+	ID         string    `gorm:"primaryKey;size:100"`
+	Password   string    `gorm:"size:100;not null"`
+	FirstName  string    `gorm:"size:100;not null"`
+	MiddleName string    `gorm:"size:100"`
+	LastName   string    `gorm:"size:100"`
+	CreatedAt  time.Time `gorm:"not null"`
+	UpdatedAt  time.Time `gorm:"not null"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineUserLog struct {
+	ID        int       `gorm:"primaryKey;autoIncrement"`
+	UserId    string    `gorm:"size:100;not null"`
+	Action    string    `gorm:"size:100;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+func (baselineUserLog) TableName() string { return "user_logs" }
+
+type baselineTodo struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	UserId      string `gorm:"size:100;not null"`
+	Title       string `gorm:"size:100;not null"`
+	Description string `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineTodo) TableName() string { return "todos" }
+
+type baselineWallet struct {
+	ID        string       `gorm:"primaryKey;size:100"`
+	UserId    string       `gorm:"size:100;not null;uniqueIndex:idx_wallets_user_id"`
+	Balance   int64        `gorm:"not null"`
+	CreatedAt time.Time    `gorm:"not null"`
+	UpdatedAt time.Time    `gorm:"not null"`
+	User      baselineUser `gorm:"foreignKey:UserId;references:ID"`
+}
+
+func (baselineWallet) TableName() string { return "wallets" }
+
+type baselineAddress struct {
+	ID        int64        `gorm:"primaryKey;autoIncrement"`
+	UserId    string       `gorm:"size:100;not null;index"`
+	Address   string       `gorm:"size:100;not null"`
+	CreatedAt time.Time    `gorm:"not null"`
+	UpdatedAt time.Time    `gorm:"not null"`
+	User      baselineUser `gorm:"foreignKey:UserId;references:ID"`
+}
+
+func (baselineAddress) TableName() string { return "addresses" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(
+				&baselineSample{},
+				&baselineUser{},
+				&baselineUserLog{},
+				&baselineTodo{},
+				&baselineWallet{},
+				&baselineAddress{},
+			)
+		},
+		Down: func(tx *gorm.DB) error {
+			// DropTable menghapus tabel dari urutan terakhir
+			return tx.Migrator().DropTable(
+				&baselineSample{},
+				&baselineUser{},
+				&baselineUserLog{},
+				&baselineTodo{},
+				&baselineWallet{},
+				&baselineAddress{},
+			)
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package belajargorm
+
+import "gorm.io/gorm"
+
+// kolom tambahan di user_logs untuk audit trail, lihat AuditPlugin
+
+type auditTrailUserLog struct {
+	ActorId  string `gorm:"size:100"`
+	Entity   string `gorm:"size:100;index:idx_user_logs_entity,priority:1"`
+	EntityId string `gorm:"size:100;index:idx_user_logs_entity,priority:2"`
+	Changes  string `gorm:"type:text"`
+}
+
+func (auditTrailUserLog) TableName() string { return "user_logs" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 2,
+		Name:    "audit trail",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"ActorId", "Entity", "EntityId", "Changes"} {
+				if err := tx.Migrator().AddColumn(&auditTrailUserLog{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateIndex(&auditTrailUserLog{}, "idx_user_logs_entity")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&auditTrailUserLog{}, "idx_user_logs_entity"); err != nil {
+				return err
+			}
+			for _, column := range []string{"ActorId", "Entity", "EntityId", "Changes"} {
+				if err := tx.Migrator().DropColumn(&auditTrailUserLog{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
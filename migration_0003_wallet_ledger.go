@@ -0,0 +1,86 @@
+package belajargorm
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// tabel wallet_entries untuk ledger double-entry, lihat WalletEntry
+// saldo wallet yang sudah ada dicatat sebagai entry "opening balance"
+// nama akun, arah entry dan ID disalin dari nilai saat migration 3 dibuat (AccountExternal, Debit/Credit dan
+// UUIDv7Generator), agar perubahan kode ledger atau DefaultIDGenerator berikutnya tidak mengubah hasil migration ini
+
+const (
+	v3AccountExternal = "@external"
+	v3Debit           = "debit"
+	v3Credit          = "credit"
+)
+
+func v3NewID() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+type ledgerWalletEntry struct {
+	ID        string    `gorm:"primaryKey;size:100"`
+	GroupId   string    `gorm:"size:100;not null;index"`
+	Account   string    `gorm:"size:100;not null;index"`
+	Direction string    `gorm:"size:10;not null"`
+	Amount    int64     `gorm:"not null"`
+	Reference string    `gorm:"size:100"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (ledgerWalletEntry) TableName() string { return "wallet_entries" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 3,
+		Name:    "wallet ledger",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&ledgerWalletEntry{}); err != nil {
+				return err
+			}
+
+			var wallets []baselineWallet
+			if err := tx.Where("balance <> 0").Order("id").Find(&wallets).Error; err != nil {
+				return err
+			}
+			now := time.Now().UTC()
+			for _, wallet := range wallets {
+				groupID, err := v3NewID()
+				if err != nil {
+					return err
+				}
+				debit, credit := v3AccountExternal, wallet.ID
+				amount := wallet.Balance
+				if amount < 0 {
+					debit, credit, amount = wallet.ID, v3AccountExternal, -amount
+				}
+				entries := []ledgerWalletEntry{
+					{GroupId: groupID, Account: debit, Direction: v3Debit, Amount: amount},
+					{GroupId: groupID, Account: credit, Direction: v3Credit, Amount: amount},
+				}
+				for i := range entries {
+					if entries[i].ID, err = v3NewID(); err != nil {
+						return err
+					}
+					entries[i].Reference = "opening balance"
+					entries[i].CreatedAt = now
+				}
+				if err := tx.Create(&entries).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&ledgerWalletEntry{})
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tabel idempotency_keys, lihat Idempotent
+
+type idempotencyKey struct {
+	Key         string    `gorm:"primaryKey;column:idempotency_key;size:100"`
+	Operation   string    `gorm:"size:100;not null"`
+	RequestHash string    `gorm:"size:64;not null"`
+	Response    string    `gorm:"type:text"`
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (idempotencyKey) TableName() string { return "idempotency_keys" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 4,
+		Name:    "idempotency keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&idempotencyKey{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&idempotencyKey{})
+		},
+	})
+}
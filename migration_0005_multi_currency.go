@@ -0,0 +1,72 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// kolom currency di wallets dan wallet_entries, serta tabel exchange_rates
+// data yang sudah ada dianggap dalam rupiah, dan karena sebelumnya disimpan dalam rupiah penuh
+// saldo dan jumlah entry dikalikan 100 menjadi minor unit IDR (sen)
+
+type multiCurrencyWallet struct {
+	Currency string `gorm:"size:3;not null;default:'IDR'"`
+}
+
+func (multiCurrencyWallet) TableName() string { return "wallets" }
+
+type multiCurrencyWalletEntry struct {
+	Currency string `gorm:"size:3;not null;default:'IDR'"`
+}
+
+func (multiCurrencyWalletEntry) TableName() string { return "wallet_entries" }
+
+type multiCurrencyExchangeRate struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	BaseCurrency  string    `gorm:"size:3;not null;index:idx_exchange_rates_pair,priority:1"`
+	QuoteCurrency string    `gorm:"size:3;not null;index:idx_exchange_rates_pair,priority:2"`
+	Rate          string    `gorm:"size:40;not null"`
+	EffectiveAt   time.Time `gorm:"not null;index:idx_exchange_rates_pair,priority:3"`
+	CreatedAt     time.Time `gorm:"not null"`
+}
+
+func (multiCurrencyExchangeRate) TableName() string { return "exchange_rates" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 5,
+		Name:    "multi currency",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&multiCurrencyWallet{}, "Currency"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().AddColumn(&multiCurrencyWalletEntry{}, "Currency"); err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE wallets SET balance = balance * 100").Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE wallet_entries SET amount = amount * 100").Error; err != nil {
+				return err
+			}
+			return tx.Migrator().CreateTable(&multiCurrencyExchangeRate{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&multiCurrencyExchangeRate{}); err != nil {
+				return err
+			}
+			// kembali ke rupiah penuh, pecahan sen dibuang (% dan / sama di semua dialect untuk bilangan bulat)
+			if err := tx.Exec("UPDATE wallets SET balance = (balance - balance % 100) / 100 WHERE currency = ?", "IDR").Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE wallet_entries SET amount = (amount - amount % 100) / 100 WHERE currency = ?", "IDR").Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&multiCurrencyWalletEntry{}, "Currency"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&multiCurrencyWallet{}, "Currency")
+		},
+	})
+}
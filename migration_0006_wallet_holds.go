@@ -0,0 +1,37 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tabel wallet_holds, lihat WalletHold
+
+type walletHold struct {
+	ID             string         `gorm:"primaryKey;size:100"`
+	WalletId       string         `gorm:"size:100;not null;index:idx_wallet_holds_wallet_status,priority:1"`
+	Amount         int64          `gorm:"not null"`
+	CapturedAmount int64          `gorm:"not null"`
+	Currency       string         `gorm:"size:3;not null"`
+	Status         string         `gorm:"size:20;not null;index:idx_wallet_holds_wallet_status,priority:2"`
+	ExpiresAt      time.Time      `gorm:"not null;index"`
+	CreatedAt      time.Time      `gorm:"not null"`
+	UpdatedAt      time.Time      `gorm:"not null"`
+	Wallet         baselineWallet `gorm:"foreignKey:WalletId;references:ID"`
+}
+
+func (walletHold) TableName() string { return "wallet_holds" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 6,
+		Name:    "wallet holds",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&walletHold{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&walletHold{})
+		},
+	})
+}
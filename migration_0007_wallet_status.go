@@ -0,0 +1,47 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// kolom status di wallets dan tabel wallet_status_changes, wallet yang sudah ada dianggap active
+
+type walletStatusWallet struct {
+	Status string `gorm:"size:20;not null;default:'active'"`
+}
+
+func (walletStatusWallet) TableName() string { return "wallets" }
+
+type walletStatusChange struct {
+	ID         int64          `gorm:"primaryKey;autoIncrement"`
+	WalletId   string         `gorm:"size:100;not null;index"`
+	FromStatus string         `gorm:"size:20;not null"`
+	ToStatus   string         `gorm:"size:20;not null"`
+	Reason     string         `gorm:"size:255;not null"`
+	ActorId    string         `gorm:"size:100"`
+	CreatedAt  time.Time      `gorm:"not null"`
+	Wallet     baselineWallet `gorm:"foreignKey:WalletId;references:ID"`
+}
+
+func (walletStatusChange) TableName() string { return "wallet_status_changes" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 7,
+		Name:    "wallet status",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&walletStatusWallet{}, "Status"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateTable(&walletStatusChange{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&walletStatusChange{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&walletStatusWallet{}, "Status")
+		},
+	})
+}
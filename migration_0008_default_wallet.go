@@ -0,0 +1,66 @@
+package belajargorm
+
+import (
+	"gorm.io/gorm"
+)
+
+// user boleh punya banyak wallet, tepat satu di antaranya default
+// unique index idx_wallets_user_id diganti index biasa dan partial unique index idx_wallets_default
+// wallet yang sudah ada menjadi default, karena sebelumnya setiap user hanya punya satu wallet
+
+type defaultWallet struct {
+	UserId    string `gorm:"size:100;not null;index:idx_wallets_user;uniqueIndex:idx_wallets_default,where:is_default"`
+	IsDefault bool   `gorm:"not null;default:false"`
+}
+
+func (defaultWallet) TableName() string { return "wallets" }
+
+// mysql tidak mendukung partial index, sehingga dipakai functional index
+// yang hanya berisi user_id untuk wallet default (NULL tidak dianggap duplikat)
+func createDefaultWalletIndex(tx *gorm.DB) error {
+	if tx.Dialector.Name() == "mysql" {
+		return tx.Exec("CREATE UNIQUE INDEX idx_wallets_default ON wallets ((CASE WHEN is_default THEN user_id END))").Error
+	}
+	return tx.Migrator().CreateIndex(&defaultWallet{}, "idx_wallets_default")
+}
+
+func init() {
+	registerMigration(Migration{
+		Version: 8,
+		Name:    "default wallet",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&defaultWallet{}, "IsDefault"); err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE wallets SET is_default = ?", true).Error; err != nil {
+				return err
+			}
+			if err := createDefaultWalletIndex(tx); err != nil {
+				return err
+			}
+			// index baru dibuat lebih dulu, mysql menolak menghapus index yang dipakai foreign key
+			if err := tx.Migrator().CreateIndex(&defaultWallet{}, "idx_wallets_user"); err != nil {
+				return err
+			}
+			// di sqlite DropColumn membuat ulang tabel tanpa index, sehingga index ini bisa sudah hilang
+			// setelah rollback migration sebelumnya
+			if !tx.Migrator().HasIndex(&baselineWallet{}, "idx_wallets_user_id") {
+				return nil
+			}
+			return tx.Migrator().DropIndex(&baselineWallet{}, "idx_wallets_user_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			// gagal jika ada user yang sudah punya lebih dari satu wallet
+			if err := tx.Migrator().CreateIndex(&baselineWallet{}, "idx_wallets_user_id"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&defaultWallet{}, "idx_wallets_user"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&defaultWallet{}, "idx_wallets_default"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&defaultWallet{}, "IsDefault")
+		},
+	})
+}
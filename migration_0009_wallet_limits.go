@@ -0,0 +1,34 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tabel wallet_limits, lihat WalletLimit
+
+type walletLimit struct {
+	WalletId           string `gorm:"primaryKey;size:100"`
+	DailyDebit         *int64
+	MonthlyDebit       *int64
+	HourlyTransactions *int64
+	CreatedAt          time.Time      `gorm:"not null"`
+	UpdatedAt          time.Time      `gorm:"not null"`
+	Wallet             baselineWallet `gorm:"foreignKey:WalletId;references:ID"`
+}
+
+func (walletLimit) TableName() string { return "wallet_limits" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 9,
+		Name:    "wallet limits",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&walletLimit{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&walletLimit{})
+		},
+	})
+}
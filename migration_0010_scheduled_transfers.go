@@ -0,0 +1,52 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tabel scheduled_transfers dan scheduled_transfer_runs, lihat ScheduledTransfer
+
+type scheduledTransfer struct {
+	ID           string    `gorm:"primaryKey;size:100"`
+	FromWalletId string    `gorm:"size:100;not null;index"`
+	ToWalletId   string    `gorm:"size:100;not null"`
+	Amount       int64     `gorm:"not null"`
+	Schedule     string    `gorm:"size:100;not null"`
+	TimeZone     string    `gorm:"size:64;not null"`
+	Status       string    `gorm:"size:20;not null;index:idx_scheduled_transfers_due,priority:1"`
+	NextRunAt    time.Time `gorm:"not null;index:idx_scheduled_transfers_due,priority:2"`
+	EndAt        *time.Time
+	CreatedAt    time.Time      `gorm:"not null"`
+	UpdatedAt    time.Time      `gorm:"not null"`
+	FromWallet   baselineWallet `gorm:"foreignKey:FromWalletId;references:ID"`
+	ToWallet     baselineWallet `gorm:"foreignKey:ToWalletId;references:ID"`
+}
+
+func (scheduledTransfer) TableName() string { return "scheduled_transfers" }
+
+type scheduledTransferRun struct {
+	ID           int64             `gorm:"primaryKey;autoIncrement"`
+	ScheduleId   string            `gorm:"size:100;not null;uniqueIndex:idx_scheduled_transfer_runs_occurrence,priority:1"`
+	ScheduledFor time.Time         `gorm:"not null;uniqueIndex:idx_scheduled_transfer_runs_occurrence,priority:2"`
+	Status       string            `gorm:"size:20;not null"`
+	Error        string            `gorm:"size:255"`
+	CreatedAt    time.Time         `gorm:"not null"`
+	Schedule     scheduledTransfer `gorm:"foreignKey:ScheduleId;references:ID"`
+}
+
+func (scheduledTransferRun) TableName() string { return "scheduled_transfer_runs" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 10,
+		Name:    "scheduled transfers",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&scheduledTransfer{}, &scheduledTransferRun{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&scheduledTransferRun{}, &scheduledTransfer{})
+		},
+	})
+}
@@ -0,0 +1,44 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tabel pending_operations untuk maker-checker, lihat PendingOperation
+
+type pendingOperation struct {
+	ID         string    `gorm:"primaryKey;size:100"`
+	Kind       string    `gorm:"size:20;not null"`
+	WalletId   string    `gorm:"size:100;not null;index"`
+	ToWalletId *string   `gorm:"size:100"`
+	Amount     int64     `gorm:"not null"`
+	Currency   string    `gorm:"size:3;not null"`
+	Reference  string    `gorm:"size:100"`
+	Status     string    `gorm:"size:20;not null;index:idx_pending_operations_status_expires,priority:1"`
+	ProposedBy string    `gorm:"size:100;not null"`
+	DecidedBy  string    `gorm:"size:100"`
+	Reason     string    `gorm:"size:255"`
+	ExpiresAt  time.Time `gorm:"not null;index:idx_pending_operations_status_expires,priority:2"`
+	DecidedAt  *time.Time
+	CreatedAt  time.Time      `gorm:"not null"`
+	UpdatedAt  time.Time      `gorm:"not null"`
+	Wallet     baselineWallet `gorm:"foreignKey:WalletId;references:ID"`
+	ToWallet   baselineWallet `gorm:"foreignKey:ToWalletId;references:ID"`
+}
+
+func (pendingOperation) TableName() string { return "pending_operations" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 11,
+		Name:    "pending operations",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&pendingOperation{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&pendingOperation{})
+		},
+	})
+}
@@ -0,0 +1,294 @@
+package belajargorm
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// bagian-bagian alamat di addresses, lihat AddressFields
+// alamat yang sudah ada dipecah dengan v12ParseAddress (salinan ParseAddress) dan kolom address tetap berisi teks aslinya
+
+type structuredAddress struct {
+	ID              int64   `gorm:"primaryKey"`
+	Address         string  `gorm:"size:100"`
+	Street          string  `gorm:"size:100"`
+	Number          string  `gorm:"size:20"`
+	RT              string  `gorm:"size:3"`
+	RW              string  `gorm:"size:3"`
+	Kelurahan       string  `gorm:"size:100"`
+	Kecamatan       string  `gorm:"size:100"`
+	City            string  `gorm:"size:100"`
+	Province        string  `gorm:"size:100"`
+	PostalCode      string  `gorm:"size:10"`
+	Country         string  `gorm:"size:2;not null;default:'ID'"`
+	ParseConfidence float64 `gorm:"not null;default:0"`
+}
+
+func (structuredAddress) TableName() string { return "addresses" }
+
+var structuredAddressColumns = []string{
+	"Street", "Number", "RT", "RW", "Kelurahan", "Kecamatan", "City", "Province", "PostalCode", "Country", "ParseConfidence",
+}
+
+func init() {
+	registerMigration(Migration{
+		Version: 12,
+		Name:    "structured address",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range structuredAddressColumns {
+				if err := tx.Migrator().AddColumn(&structuredAddress{}, column); err != nil {
+					return err
+				}
+			}
+
+			var batch []structuredAddress
+			return tx.Select("id", "address").FindInBatches(&batch, 500, func(batchTx *gorm.DB, _ int) error {
+				for _, address := range batch {
+					parsed := v12ParseAddress(address.Address)
+					err := batchTx.Model(&structuredAddress{}).Where("id = ?", address.ID).Updates(map[string]interface{}{
+						"street":           parsed.Street,
+						"number":           parsed.Number,
+						"rt":               parsed.RT,
+						"rw":               parsed.RW,
+						"kelurahan":        parsed.Kelurahan,
+						"kecamatan":        parsed.Kecamatan,
+						"city":             parsed.City,
+						"province":         parsed.Province,
+						"postal_code":      parsed.PostalCode,
+						"country":          parsed.Country,
+						"parse_confidence": parsed.ParseConfidence,
+					}).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			for i := len(structuredAddressColumns) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropColumn(&structuredAddress{}, structuredAddressColumns[i]); err != nil {
+					return err
+				}
+			}
+			return restoreIndexes(tx, &baselineAddress{})
+		},
+	})
+}
+
+var (
+	v12PostalCodePattern = regexp.MustCompile(`\b\d{5}\b`)
+	v12RtRwPattern       = regexp.MustCompile(`(?i)\bRT\s*\.?\s*/\s*RW\s*\.?\s*:?\s*(\d{1,3})\s*/\s*(\d{1,3})\b|\bRT\s*\.?\s*:?\s*(\d{1,3})\s*(?:/\s*(?:RW\s*\.?\s*:?\s*)?|\s+RW\s*\.?\s*:?\s*)(\d{1,3})\b`)
+	v12RtPattern         = regexp.MustCompile(`(?i)\bRT\s*\.?\s*:?\s*(\d{1,3})\b`)
+	v12RwPattern         = regexp.MustCompile(`(?i)\bRW\s*\.?\s*:?\s*(\d{1,3})\b`)
+	v12NumberPattern     = regexp.MustCompile(`(?i)\b(?:No|Nomor|Nmr)\s*\.?\s*:?\s*(\d+[A-Za-z]?(?:[/-]\w+)?)`)
+	v12SpacePattern      = regexp.MustCompile(`\s+`)
+)
+
+var (
+	v12StreetPrefixes    = []string{"jl.", "jl ", "jln", "jalan", "gg.", "gg ", "gang", "komp", "komplek", "kompleks", "perum", "perumahan", "blok"}
+	v12KelurahanPrefixes = []string{"kelurahan", "kel.", "kel ", "desa", "ds."}
+	v12KecamatanPrefixes = []string{"kecamatan", "kec.", "kec "}
+	v12CityPrefixes      = []string{"kota ", "kabupaten", "kab.", "kab "}
+	v12ProvincePrefixes  = []string{"provinsi", "prov.", "prov "}
+)
+
+// nama provinsi dan singkatan yang umum, kunci ditulis huruf kecil tanpa titik
+var v12Provinces = v12ProvinceNames()
+
+func v12ProvinceNames() map[string]string {
+	provinces := map[string]string{}
+	for _, name := range []string{
+		"Aceh", "Sumatera Utara", "Sumatera Barat", "Riau", "Kepulauan Riau", "Jambi", "Sumatera Selatan",
+		"Kepulauan Bangka Belitung", "Bengkulu", "Lampung", "DKI Jakarta", "Jawa Barat", "Banten", "Jawa Tengah",
+		"DI Yogyakarta", "Jawa Timur", "Bali", "Nusa Tenggara Barat", "Nusa Tenggara Timur", "Kalimantan Barat",
+		"Kalimantan Tengah", "Kalimantan Selatan", "Kalimantan Timur", "Kalimantan Utara", "Sulawesi Utara",
+		"Gorontalo", "Sulawesi Tengah", "Sulawesi Barat", "Sulawesi Selatan", "Sulawesi Tenggara", "Maluku",
+		"Maluku Utara", "Papua", "Papua Barat", "Papua Barat Daya", "Papua Selatan", "Papua Tengah", "Papua Pegunungan",
+	} {
+		provinces[v12ProvinceKey(name)] = name
+	}
+	for alias, name := range map[string]string{
+		"jakarta": "DKI Jakarta", "daerah khusus ibukota jakarta": "DKI Jakarta",
+		"yogyakarta": "DI Yogyakarta", "diy": "DI Yogyakarta", "daerah istimewa yogyakarta": "DI Yogyakarta",
+		"jabar": "Jawa Barat", "jateng": "Jawa Tengah", "jatim": "Jawa Timur",
+		"sumut": "Sumatera Utara", "sumbar": "Sumatera Barat", "sumsel": "Sumatera Selatan",
+		"kepri": "Kepulauan Riau", "babel": "Kepulauan Bangka Belitung", "bangka belitung": "Kepulauan Bangka Belitung",
+		"ntb": "Nusa Tenggara Barat", "ntt": "Nusa Tenggara Timur",
+		"kalbar": "Kalimantan Barat", "kalteng": "Kalimantan Tengah", "kalsel": "Kalimantan Selatan",
+		"kaltim": "Kalimantan Timur", "kaltara": "Kalimantan Utara",
+		"sulut": "Sulawesi Utara", "sulteng": "Sulawesi Tengah", "sulbar": "Sulawesi Barat",
+		"sulsel": "Sulawesi Selatan", "sultra": "Sulawesi Tenggara", "malut": "Maluku Utara",
+	} {
+		provinces[alias] = name
+	}
+	return provinces
+}
+
+func v12ProvinceKey(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, ".", ""))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// bobot setiap bagian alamat untuk Confidence, jumlahnya 1
+const (
+	v12WeightStreet     = 0.30
+	v12WeightNumber     = 0.10
+	v12WeightRTRW       = 0.10
+	v12WeightKelurahan  = 0.10
+	v12WeightKecamatan  = 0.10
+	v12WeightCity       = 0.15
+	v12WeightProvince   = 0.10
+	v12WeightPostalCode = 0.05
+	v12PenaltyUnknown   = 0.10
+)
+
+// v12ParseAddress adalah salinan ParseAddress saat migration ini dibuat, hasilnya berisi bagian alamat
+// dan ParseConfidence, perubahan ParseAddress berikutnya tidak mengubah hasil migration ini
+func v12ParseAddress(text string) structuredAddress {
+	text = v12SpacePattern.ReplaceAllString(strings.TrimSpace(text), " ")
+	parsed := structuredAddress{Country: "ID"}
+	if text == "" {
+		return parsed
+	}
+	score := 0.0
+
+	if codes := v12PostalCodePattern.FindAllStringIndex(text, -1); len(codes) > 0 {
+		last := codes[len(codes)-1]
+		parsed.PostalCode = text[last[0]:last[1]]
+		text = text[:last[0]] + text[last[1]:]
+		score += v12WeightPostalCode
+	}
+
+	if m := v12RtRwPattern.FindStringSubmatchIndex(text); m != nil {
+		rt, rw := 2, 4
+		if m[rt] < 0 {
+			rt, rw = 6, 8
+		}
+		parsed.RT, parsed.RW = v12PadRTRW(text[m[rt]:m[rt+1]]), v12PadRTRW(text[m[rw]:m[rw+1]])
+		text = text[:m[0]] + text[m[1]:]
+	} else {
+		if m := v12RtPattern.FindStringSubmatchIndex(text); m != nil {
+			parsed.RT = v12PadRTRW(text[m[2]:m[3]])
+			text = text[:m[0]] + text[m[1]:]
+		}
+		if m := v12RwPattern.FindStringSubmatchIndex(text); m != nil {
+			parsed.RW = v12PadRTRW(text[m[2]:m[3]])
+			text = text[:m[0]] + text[m[1]:]
+		}
+	}
+	if parsed.RT != "" && parsed.RW != "" {
+		score += v12WeightRTRW
+	} else if parsed.RT != "" || parsed.RW != "" {
+		score += v12WeightRTRW / 2
+	}
+
+	if m := v12NumberPattern.FindStringSubmatchIndex(text); m != nil {
+		parsed.Number = text[m[2]:m[3]]
+		text = text[:m[0]] + text[m[1]:]
+		score += v12WeightNumber
+	}
+
+	var unlabeled []string
+	for i, segment := range strings.Split(text, ",") {
+		segment = strings.Trim(strings.TrimSpace(segment), " .,/-")
+		if segment == "" {
+			continue
+		}
+		lower := strings.ToLower(segment)
+
+		if value, ok := v12CutPrefix(segment, lower, v12KelurahanPrefixes); ok && parsed.Kelurahan == "" {
+			parsed.Kelurahan = value
+			score += v12WeightKelurahan
+		} else if value, ok := v12CutPrefix(segment, lower, v12KecamatanPrefixes); ok && parsed.Kecamatan == "" {
+			parsed.Kecamatan = value
+			score += v12WeightKecamatan
+		} else if value, ok := v12CutPrefix(segment, lower, v12ProvincePrefixes); ok && parsed.Province == "" {
+			parsed.Province = value
+			if name, ok := v12Provinces[v12ProvinceKey(value)]; ok {
+				parsed.Province = name
+			}
+			score += v12WeightProvince
+		} else if parsed.City == "" && v12HasAnyPrefix(lower, v12CityPrefixes) {
+			parsed.City = v12CityName(segment, lower)
+			score += v12WeightCity
+		} else if name, ok := v12Provinces[v12ProvinceKey(segment)]; ok && i > 0 && parsed.Province == "" {
+			parsed.Province = name
+			score += v12WeightProvince
+		} else if lower == "indonesia" {
+			parsed.Country = "ID"
+		} else if parsed.Street == "" && (i == 0 || v12HasAnyPrefix(lower, v12StreetPrefixes)) {
+			parsed.Street = segment
+			score += v12WeightStreet
+		} else {
+			unlabeled = append(unlabeled, segment)
+		}
+	}
+
+	// bagian tanpa penanda diisi dari belakang: kota, kecamatan, lalu kelurahan
+	for i := len(unlabeled) - 1; i >= 0; i-- {
+		switch {
+		case parsed.City == "":
+			parsed.City = unlabeled[i]
+			score += v12WeightCity / 2
+		case parsed.Kecamatan == "":
+			parsed.Kecamatan = unlabeled[i]
+			score += v12WeightKecamatan / 2
+		case parsed.Kelurahan == "":
+			parsed.Kelurahan = unlabeled[i]
+			score += v12WeightKelurahan / 2
+		default:
+			score -= v12PenaltyUnknown
+		}
+	}
+
+	parsed.ParseConfidence = math.Round(math.Max(0, math.Min(1, score))*100) / 100
+	return parsed
+}
+
+func v12PadRTRW(s string) string {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return s
+	}
+	return fmt.Sprintf("%03d", n)
+}
+
+func v12HasAnyPrefix(lower string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// v12CutPrefix membuang penanda seperti "Kec." dari segment, lower adalah segment dalam huruf kecil
+func v12CutPrefix(segment, lower string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			value := strings.Trim(segment[len(prefix):], " .:")
+			return value, value != ""
+		}
+	}
+	return "", false
+}
+
+// v12CityName membuang awalan "Kota" dan menyeragamkan "Kab." menjadi "Kabupaten"
+// karena kota dan kabupaten dengan nama yang sama adalah daerah yang berbeda
+func v12CityName(segment, lower string) string {
+	if strings.HasPrefix(lower, "kota ") {
+		return strings.TrimSpace(segment[len("kota "):])
+	}
+	for _, prefix := range []string{"kabupaten", "kab.", "kab "} {
+		if strings.HasPrefix(lower, prefix) {
+			return "Kabupaten " + strings.Trim(segment[len(prefix):], " .:")
+		}
+	}
+	return segment
+}
@@ -0,0 +1,49 @@
+package belajargorm
+
+import (
+	"gorm.io/gorm"
+)
+
+// kolom type dan is_primary di addresses
+// alamat yang sudah ada menjadi alamat home, dan alamat pertama setiap user menjadi primary
+
+type addressRole struct {
+	UserId    string `gorm:"size:100;not null;uniqueIndex:idx_addresses_primary,priority:1,where:is_primary"`
+	Type      string `gorm:"size:20;not null;default:'home';uniqueIndex:idx_addresses_primary,priority:2"`
+	IsPrimary bool   `gorm:"not null;default:false"`
+}
+
+func (addressRole) TableName() string { return "addresses" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 13,
+		Name:    "address roles",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&addressRole{}, "Type"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().AddColumn(&addressRole{}, "IsPrimary"); err != nil {
+				return err
+			}
+			// subquery dibungkus derived table karena mysql menolak UPDATE yang membaca tabel yang sama
+			err := tx.Exec("UPDATE addresses SET is_primary = ? WHERE id IN (SELECT id FROM (SELECT MIN(id) AS id FROM addresses GROUP BY user_id) firsts)", true).Error
+			if err != nil {
+				return err
+			}
+			return createPartialUniqueIndex(tx, &addressRole{}, "idx_addresses_primary")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&addressRole{}, "idx_addresses_primary"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&addressRole{}, "IsPrimary"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&addressRole{}, "Type"); err != nil {
+				return err
+			}
+			return restoreIndexes(tx, &baselineAddress{})
+		},
+	})
+}
@@ -0,0 +1,139 @@
+package belajargorm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// kolom fingerprint di addresses untuk mencari alamat yang sama, lihat AddressFields.Fingerprint
+// fingerprint alamat yang sudah ada dihitung dengan v14Fingerprint (salinan AddressFields.Fingerprint),
+// duplikatnya tidak dihapus di sini karena penghapusan data dilakukan lewat AddressService.DedupeAddresses
+
+type addressFingerprint struct {
+	ID          int64  `gorm:"primaryKey"`
+	UserId      string `gorm:"size:100;index:idx_addresses_fingerprint,priority:1"`
+	Street      string `gorm:"size:100"`
+	Number      string `gorm:"size:20"`
+	RT          string `gorm:"size:3"`
+	RW          string `gorm:"size:3"`
+	Kelurahan   string `gorm:"size:100"`
+	Kecamatan   string `gorm:"size:100"`
+	City        string `gorm:"size:100"`
+	Province    string `gorm:"size:100"`
+	PostalCode  string `gorm:"size:10"`
+	Country     string `gorm:"size:2;not null;default:'ID'"`
+	Fingerprint string `gorm:"size:64;index:idx_addresses_fingerprint,priority:2"`
+}
+
+func (addressFingerprint) TableName() string { return "addresses" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 14,
+		Name:    "address fingerprint",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&addressFingerprint{}, "Fingerprint"); err != nil {
+				return err
+			}
+
+			var batch []addressFingerprint
+			err := tx.FindInBatches(&batch, 500, func(batchTx *gorm.DB, _ int) error {
+				for _, address := range batch {
+					err := batchTx.Model(&addressFingerprint{}).Where("id = ?", address.ID).
+						Update("fingerprint", v14Fingerprint(address)).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&addressFingerprint{}, "idx_addresses_fingerprint")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&addressFingerprint{}, "idx_addresses_fingerprint"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&addressFingerprint{}, "Fingerprint"); err != nil {
+				return err
+			}
+			return restoreIndexes(tx, &baselineAddress{}, &addressRole{})
+		},
+	})
+}
+
+// salinan AddressFields.Fingerprint dan NormalizeAddress saat migration 14 dibuat,
+// agar perubahan normalisasi berikutnya tidak mengubah hasil migration ini
+
+var v14Abbreviations = map[string]string{
+	"jl":      "jalan",
+	"jln":     "jalan",
+	"gg":      "gang",
+	"nomor":   "no",
+	"nmr":     "no",
+	"kel":     "kelurahan",
+	"kec":     "kecamatan",
+	"kab":     "kabupaten",
+	"prov":    "provinsi",
+	"komp":    "kompleks",
+	"komplek": "kompleks",
+	"perum":   "perumahan",
+	"blk":     "blok",
+	"jend":    "jenderal",
+}
+
+func v14Fingerprint(a addressFingerprint) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		v14Normalize(a.Street),
+		v14Normalize(a.Number),
+		v14TrimLeadingZeros(a.RT),
+		v14TrimLeadingZeros(a.RW),
+		v14Normalize(a.Kelurahan),
+		v14Normalize(a.Kecamatan),
+		v14Normalize(a.City),
+		v14Normalize(a.Province),
+		strings.TrimSpace(a.PostalCode),
+		strings.ToUpper(strings.TrimSpace(a.Country)),
+	}, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+func v14Normalize(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '/' || r == '-':
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, text)
+
+	tokens := strings.Fields(text)
+	for i, token := range tokens {
+		if expanded, ok := v14Abbreviations[token]; ok {
+			tokens[i] = expanded
+			continue
+		}
+		parts := strings.Split(token, "/")
+		for j, part := range parts {
+			parts[j] = v14TrimLeadingZeros(part)
+		}
+		tokens[i] = strings.Join(parts, "/")
+	}
+	return strings.Join(tokens, " ")
+}
+
+func v14TrimLeadingZeros(s string) string {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return s
+	}
+	if trimmed := strings.TrimLeft(s, "0"); trimmed != "" {
+		return trimmed
+	}
+	return "0"
+}
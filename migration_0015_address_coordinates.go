@@ -0,0 +1,42 @@
+package belajargorm
+
+import (
+	"gorm.io/gorm"
+)
+
+// kolom latitude dan longitude di addresses, alamat yang sudah ada dibiarkan tanpa koordinat (NULL)
+
+type addressCoordinates struct {
+	Latitude  *float64 `gorm:"index:idx_addresses_location,priority:1"`
+	Longitude *float64 `gorm:"index:idx_addresses_location,priority:2"`
+}
+
+func (addressCoordinates) TableName() string { return "addresses" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 15,
+		Name:    "address coordinates",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&addressCoordinates{}, "Latitude"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().AddColumn(&addressCoordinates{}, "Longitude"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&addressCoordinates{}, "idx_addresses_location")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&addressCoordinates{}, "idx_addresses_location"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&addressCoordinates{}, "Longitude"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&addressCoordinates{}, "Latitude"); err != nil {
+				return err
+			}
+			return restoreIndexes(tx, &baselineAddress{}, &addressRole{}, &addressFingerprint{})
+		},
+	})
+}
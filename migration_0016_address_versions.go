@@ -0,0 +1,53 @@
+package belajargorm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tabel address_versions untuk riwayat alamat, lihat AddressVersion
+// setiap alamat yang sudah ada mendapat satu versi yang berlaku sejak created_at
+
+type addressVersion struct {
+	ID         int64  `gorm:"primaryKey"`
+	AddressId  int64  `gorm:"not null;index:idx_address_versions_validity,priority:1"`
+	UserId     string `gorm:"size:100"`
+	Type       string `gorm:"size:20;not null"`
+	Address    string `gorm:"size:100"`
+	Street     string `gorm:"size:100"`
+	Number     string `gorm:"size:20"`
+	RT         string `gorm:"size:3"`
+	RW         string `gorm:"size:3"`
+	Kelurahan  string `gorm:"size:100"`
+	Kecamatan  string `gorm:"size:100"`
+	City       string `gorm:"size:100"`
+	Province   string `gorm:"size:100"`
+	PostalCode string `gorm:"size:10"`
+	Country    string `gorm:"size:2;not null;default:'ID'"`
+	Latitude   *float64
+	Longitude  *float64
+	ValidFrom  time.Time `gorm:"not null;index:idx_address_versions_validity,priority:2"`
+	ValidTo    *time.Time
+	ActorId    string `gorm:"size:100"`
+}
+
+func (addressVersion) TableName() string { return "address_versions" }
+
+func init() {
+	registerMigration(Migration{
+		Version: 16,
+		Name:    "address versions",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&addressVersion{}); err != nil {
+				return err
+			}
+			columns := "user_id, type, address, street, number, rt, rw, kelurahan, kecamatan, city, province, postal_code, country, latitude, longitude"
+			return tx.Exec("INSERT INTO address_versions (address_id, "+columns+", valid_from) "+
+				"SELECT id, "+columns+", COALESCE(created_at, ?) FROM addresses", time.Now().UTC()).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&addressVersion{})
+		},
+	})
+}
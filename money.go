@@ -0,0 +1,229 @@
+package belajargorm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("belajargorm: currency mismatch")
+	ErrUnknownCurrency  = errors.New("belajargorm: unknown currency")
+	ErrMoneyOverflow    = errors.New("belajargorm: money amount overflows int64")
+)
+
+// DefaultCurrency dipakai oleh hook BeforeCreate Wallet jika Currency masih kosong
+// ubah hanya saat inisialisasi aplikasi, bukan saat aplikasi sedang berjalan
+var DefaultCurrency = "IDR"
+
+// jumlah digit minor unit setiap mata uang menurut ISO 4217
+var currencyExponents = map[string]int{
+	"AUD": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"IDR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MYR": 2,
+	"SGD": 2,
+	"USD": 2,
+}
+
+// CurrencyExponent mengembalikan jumlah digit minor unit mata uang, contoh USD 2 (sen) dan JPY 0
+func CurrencyExponent(currency string) (int, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return exponent, nil
+}
+
+// Money adalah jumlah uang dalam minor unit (misalnya sen) beserta kode mata uang ISO 4217
+// operasi aritmatika menolak mata uang yang berbeda dengan ErrCurrencyMismatch
+//
+// di database Money disimpan sebagai string seperti "USD 12.34"
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney membuat Money dan memastikan kode mata uangnya dikenal
+func NewMoney(amount int64, currency string) (Money, error) {
+	if _, err := CurrencyExponent(currency); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// ParseMoney membaca format yang dihasilkan Money.String, contoh "IDR 15000.00" atau "JPY 500"
+func ParseMoney(s string) (Money, error) {
+	currency, number, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return Money{}, fmt.Errorf("belajargorm: invalid money %q", s)
+	}
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	negative := strings.HasPrefix(number, "-")
+	number = strings.TrimPrefix(number, "-")
+	whole, fraction, _ := strings.Cut(number, ".")
+	if whole == "" || len(fraction) > exponent || strings.ContainsAny(whole+fraction, "+-") {
+		return Money{}, fmt.Errorf("belajargorm: invalid money %q", s)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("belajargorm: invalid money %q: %w", s, err)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func (m Money) String() string {
+	return m.Currency + " " + m.Decimal()
+}
+
+// Decimal mengembalikan jumlah dalam major unit tanpa kode mata uang, contoh "12.34" untuk USD 1234
+func (m Money) Decimal() string {
+	sign := ""
+	amount := new(big.Int).SetInt64(m.Amount)
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount.Neg(amount)
+	}
+	digits := amount.String()
+	exponent, err := CurrencyExponent(m.Currency)
+	if err != nil || exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	cut := len(digits) - exponent
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return m.Add(other.Neg())
+}
+
+// Cmp mengembalikan -1, 0 atau 1 seperti bytes.Compare
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Convert mengubah m ke mata uang quote memakai rate (1 unit m.Currency = rate unit quote)
+// hasil dibulatkan ke minor unit terdekat, setengah dibulatkan menjauhi nol
+func (m Money) Convert(quote string, rate *big.Rat) (Money, error) {
+	from, err := CurrencyExponent(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	to, err := CurrencyExponent(quote)
+	if err != nil {
+		return Money{}, err
+	}
+	if rate == nil || rate.Sign() <= 0 {
+		return Money{}, fmt.Errorf("belajargorm: invalid exchange rate %v", rate)
+	}
+
+	num := new(big.Int).Mul(big.NewInt(m.Amount), rate.Num())
+	num.Mul(num, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(to)), nil))
+	den := new(big.Int).Mul(rate.Denom(), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(from)), nil))
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	}
+	if !quo.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: quo.Int64(), Currency: quote}, nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	if m.Currency == "" {
+		return nil, nil
+	}
+	if _, err := CurrencyExponent(m.Currency); err != nil {
+		return nil, err
+	}
+	return m.String(), nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch value := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case string:
+		s = value
+	case []byte:
+		s = string(value)
+	default:
+		return fmt.Errorf("belajargorm: cannot scan %T into Money", src)
+	}
+	money, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+func (Money) GormDataType() string {
+	return "string"
+}
@@ -0,0 +1,97 @@
+package belajargorm
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoneyString(t *testing.T) {
+	cases := map[string]Money{
+		"IDR 15000.00": {Amount: 1500000, Currency: "IDR"},
+		"USD 0.05":     {Amount: 5, Currency: "USD"},
+		"USD -12.34":   {Amount: -1234, Currency: "USD"},
+		"JPY 500":      {Amount: 500, Currency: "JPY"},
+		"KWD 1.005":    {Amount: 1005, Currency: "KWD"},
+	}
+	for s, money := range cases {
+		assert.Equal(t, s, money.String())
+		parsed, err := ParseMoney(s)
+		assert.Nil(t, err)
+		assert.Equal(t, money, parsed)
+	}
+
+	parsed, err := ParseMoney("USD 7.5")
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 750, Currency: "USD"}, parsed)
+
+	for _, s := range []string{"USD", "USD 1.234", "XXX 1", "USD abc", "USD --1", "USD 1.-2"} {
+		_, err := ParseMoney(s)
+		assert.NotNil(t, err, s)
+	}
+	_, err = NewMoney(100, "usd")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	idr := Money{Amount: 1000, Currency: "IDR"}
+	usd := Money{Amount: 1000, Currency: "USD"}
+
+	sum, err := idr.Add(Money{Amount: 500, Currency: "IDR"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1500), sum.Amount)
+
+	diff, err := idr.Sub(Money{Amount: 1500, Currency: "IDR"})
+	assert.Nil(t, err)
+	assert.True(t, diff.IsNegative())
+
+	_, err = idr.Add(usd)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = idr.Sub(usd)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = idr.Cmp(usd)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	cmp, err := idr.Cmp(sum)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, cmp)
+
+	_, err = Money{Amount: math.MaxInt64, Currency: "IDR"}.Add(Money{Amount: 1, Currency: "IDR"})
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+}
+
+func TestMoneyConvert(t *testing.T) {
+	// 1 USD = 15750.5 IDR
+	rate := big.NewRat(31501, 2)
+
+	idr, err := Money{Amount: 1234, Currency: "USD"}.Convert("IDR", rate)
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 19436117, Currency: "IDR"}, idr) // 194361.17
+
+	// 1 USD = 155.5 JPY, 0.01 USD = 1.555 JPY dibulatkan menjadi 2
+	jpy, err := Money{Amount: 1, Currency: "USD"}.Convert("JPY", big.NewRat(311, 2))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), jpy.Amount)
+	jpy, err = Money{Amount: -1, Currency: "USD"}.Convert("JPY", big.NewRat(311, 2))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-2), jpy.Amount)
+
+	_, err = idr.Convert("USD", big.NewRat(0, 1))
+	assert.NotNil(t, err)
+}
+
+func TestMoneyScanValue(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	var money Money
+	err := db.Raw("SELECT ?", Money{Amount: 1234, Currency: "USD"}).Row().Scan(&money)
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 1234, Currency: "USD"}, money)
+
+	err = db.Raw("SELECT NULL").Row().Scan(&money)
+	assert.Nil(t, err)
+	assert.Equal(t, Money{}, money)
+}
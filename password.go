@@ -0,0 +1,113 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// PasswordHasher membuat dan memverifikasi hash password
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) bool
+	// NeedsRehash bernilai true jika hash dibuat dengan parameter yang berbeda dengan sekarang
+	NeedsRehash(hash string) bool
+}
+
+// BcryptHasher adalah PasswordHasher menggunakan bcrypt
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) cost() int {
+	if h.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.Cost
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost())
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h BcryptHasher) Verify(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost()
+}
+
+// DefaultPasswordHasher dipakai oleh hook User dan VerifyPassword
+// ubah hanya saat inisialisasi aplikasi, bukan saat aplikasi sedang berjalan
+var DefaultPasswordHasher PasswordHasher = BcryptHasher{Cost: bcrypt.DefaultCost}
+
+// ErrInvalidCredentials dikembalikan Authenticate jika user tidak ditemukan atau password salah
+var ErrInvalidCredentials = errors.New("belajargorm: invalid credentials")
+
+// HashPassword membuat hash password, password kosong dikembalikan apa adanya
+// input yang terlihat seperti hash tetap di-hash, agar pemanggil tidak bisa menyimpan hash yang sudah ia ketahui
+func HashPassword(password string) (string, error) {
+	if password == "" {
+		return password, nil
+	}
+	hash, err := DefaultPasswordHasher.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("belajargorm: hash password: %w", err)
+	}
+	return hash, nil
+}
+
+// hashPasswordInMap dipakai untuk Update("password", ...) dan Updates(map[string]interface{}{...})
+func hashPasswordInMap(values map[string]interface{}) error {
+	for key, value := range values {
+		if key != "password" && key != "Password" {
+			continue
+		}
+		password, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("belajargorm: password must be a string, got %T", value)
+		}
+		hash, err := HashPassword(password)
+		if err != nil {
+			return err
+		}
+		values[key] = hash
+	}
+	return nil
+}
+
+// Authenticate mencari user berdasarkan id lalu memverifikasi password
+// jika hash dibuat dengan parameter lama, hash akan diperbarui secara otomatis
+func Authenticate(ctx context.Context, db *gorm.DB, userID, password string) (*User, error) {
+	var user User
+	err := db.WithContext(ctx).Take(&user, "id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	oldHash := user.Password
+	if !user.VerifyPassword(password) {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Password != oldHash {
+		err := db.WithContext(ctx).Model(&User{}).
+			Where("id = ? AND password = ?", user.ID, oldHash).
+			UpdateColumn("password", user.Password).Error
+		if err != nil {
+			return nil, fmt.Errorf("belajargorm: rehash password: %w", err)
+		}
+	}
+	return &user, nil
+}
@@ -0,0 +1,134 @@
+package belajargorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHashedOnCreate(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	user := User{ID: "50", Password: "rahasia", Name: Name{FirstName: "User 50"}}
+	err := db.Create(&user).Error
+	assert.Nil(t, err)
+	assert.NotEqual(t, "rahasia", user.Password)
+
+	var stored User
+	err = db.Take(&stored, "id = ?", "50").Error
+	assert.Nil(t, err)
+	assert.True(t, stored.VerifyPassword("rahasia"))
+	assert.False(t, stored.VerifyPassword("salah"))
+
+	// menyimpan ulang tidak membuat hash dari hash
+	err = db.Save(&stored).Error
+	assert.Nil(t, err)
+	assert.True(t, stored.VerifyPassword("rahasia"))
+}
+
+func TestPasswordHashedOnUpdate(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	updates := []func() error{
+		func() error {
+			return db.Model(&User{}).Where("id = ?", "1").Update("password", "diubah").Error
+		},
+		func() error {
+			return db.Model(&User{}).Where("id = ?", "1").Updates(map[string]interface{}{"password": "diubah"}).Error
+		},
+		func() error {
+			return db.Model(&User{ID: "1"}).Updates(User{Password: "diubah"}).Error
+		},
+		func() error {
+			var user User
+			if err := db.Take(&user, "id = ?", "1").Error; err != nil {
+				return err
+			}
+			user.Password = "diubah"
+			return db.Save(&user).Error
+		},
+	}
+
+	for _, update := range updates {
+		err := update()
+		assert.Nil(t, err)
+
+		var user User
+		err = db.Take(&user, "id = ?", "1").Error
+		assert.Nil(t, err)
+		assert.NotEqual(t, "diubah", user.Password)
+		assert.True(t, user.VerifyPassword("diubah"))
+	}
+}
+
+func TestAuthenticateRehash(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	_, err := Authenticate(ctx, db, "1", "salah")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = Authenticate(ctx, db, "404", "rahasia")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	var before User
+	assert.Nil(t, db.Take(&before, "id = ?", "1").Error)
+	cost, _ := bcrypt.Cost([]byte(before.Password))
+	assert.Equal(t, bcrypt.MinCost, cost)
+
+	// test ini tidak paralel karena mengubah DefaultPasswordHasher
+	old := DefaultPasswordHasher
+	DefaultPasswordHasher = BcryptHasher{Cost: bcrypt.MinCost + 1}
+	defer func() { DefaultPasswordHasher = old }()
+
+	user, err := Authenticate(ctx, db, "1", "rahasia")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", user.ID)
+
+	var after User
+	assert.Nil(t, db.Take(&after, "id = ?", "1").Error)
+	cost, _ = bcrypt.Cost([]byte(after.Password))
+	assert.Equal(t, bcrypt.MinCost+1, cost)
+	assert.True(t, after.VerifyPassword("rahasia"))
+}
+
+func TestPasswordHashInputIsHashed(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	// hash yang diketahui pemanggil tidak boleh tersimpan apa adanya
+	known, err := DefaultPasswordHasher.Hash("dikenal")
+	assert.Nil(t, err)
+
+	updates := []func() error{
+		func() error {
+			return db.Create(&User{ID: "50", Password: known, Name: Name{FirstName: "User 50"}}).Error
+		},
+		func() error {
+			return db.Model(&User{}).Where("id = ?", "50").Update("password", known).Error
+		},
+		func() error {
+			return db.Model(&User{ID: "50"}).Updates(User{Password: known}).Error
+		},
+		func() error {
+			var user User
+			if err := db.Take(&user, "id = ?", "50").Error; err != nil {
+				return err
+			}
+			user.Password = known
+			return db.Save(&user).Error
+		},
+	}
+	for _, update := range updates {
+		assert.Nil(t, update())
+
+		var user User
+		assert.Nil(t, db.Take(&user, "id = ?", "50").Error)
+		assert.NotEqual(t, known, user.Password)
+		assert.False(t, user.VerifyPassword("dikenal"))
+		assert.True(t, user.VerifyPassword(known))
+	}
+}
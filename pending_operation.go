@@ -0,0 +1,320 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// aksi maker-checker yang dicatat di user_logs
+const (
+	AuditPropose AuditAction = "propose"
+	AuditApprove AuditAction = "approve"
+	AuditReject  AuditAction = "reject"
+	AuditExpire  AuditAction = "expire"
+)
+
+// DefaultApprovalTTL adalah batas waktu persetujuan jika PendingOperation.ExpiresAt kosong
+const DefaultApprovalTTL = 24 * time.Hour
+
+var (
+	ErrApprovalRequired       = errors.New("belajargorm: operation requires approval")
+	ErrActorRequired          = errors.New("belajargorm: actor is required")
+	ErrSameApprover           = errors.New("belajargorm: operation must be checked by a different user")
+	ErrOperationNotFound      = errors.New("belajargorm: pending operation not found")
+	ErrOperationNotPending    = errors.New("belajargorm: operation is not pending")
+	ErrOperationExpired       = errors.New("belajargorm: operation has expired")
+	ErrInvalidOperationKind   = errors.New("belajargorm: invalid operation kind")
+	ErrMissingTargetWallet    = errors.New("belajargorm: transfer requires a target wallet")
+	ErrUnexpectedTargetWallet = errors.New("belajargorm: only transfer has a target wallet")
+)
+
+// OperationKind adalah operasi WalletService yang bisa diajukan lewat PendingOperation
+type OperationKind string
+
+const (
+	OperationTransfer OperationKind = "transfer"
+	OperationDeposit  OperationKind = "deposit"
+	OperationWithdraw OperationKind = "withdraw"
+)
+
+// OperationStatus adalah status PendingOperation
+//
+//	pending -> approved / rejected / expired
+type OperationStatus string
+
+const (
+	OperationPending  OperationStatus = "pending"
+	OperationApproved OperationStatus = "approved"
+	OperationRejected OperationStatus = "rejected"
+	OperationExpired  OperationStatus = "expired"
+)
+
+// PendingOperation adalah operasi saldo yang diajukan oleh satu user (maker)
+// dan baru dijalankan setelah disetujui user lain (checker)
+// WalletId adalah wallet asal untuk transfer dan withdraw, atau wallet tujuan untuk deposit
+// Amount dalam minor unit Currency, yaitu mata uang WalletId
+type PendingOperation struct {
+	ID         string          `gorm:"primaryKey;column:id;size:100"`
+	Kind       OperationKind   `gorm:"column:kind;size:20;not null"`
+	WalletId   string          `gorm:"column:wallet_id;size:100;not null;index"`
+	ToWalletId *string         `gorm:"column:to_wallet_id;size:100"`
+	Amount     int64           `gorm:"column:amount;not null"`
+	Currency   string          `gorm:"column:currency;size:3;not null"`
+	Reference  string          `gorm:"column:reference;size:100"`
+	Status     OperationStatus `gorm:"column:status;size:20;not null;index:idx_pending_operations_status_expires,priority:1"`
+	ProposedBy string          `gorm:"column:proposed_by;size:100;not null"`
+	DecidedBy  string          `gorm:"column:decided_by;size:100"`
+	Reason     string          `gorm:"column:reason;size:255"`
+	ExpiresAt  time.Time       `gorm:"column:expires_at;not null;index:idx_pending_operations_status_expires,priority:2"`
+	DecidedAt  *time.Time      `gorm:"column:decided_at"`
+	CreatedAt  time.Time       `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time       `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	Wallet     *Wallet         `gorm:"foreignKey:wallet_id;references:id"`
+	ToWallet   *Wallet         `gorm:"foreignKey:to_wallet_id;references:id"`
+}
+
+func (o *PendingOperation) TableName() string {
+	return "pending_operations"
+}
+
+// hook BeforeCreate mengisi ID jika masih kosong, lihat DefaultIDGenerator
+func (o *PendingOperation) BeforeCreate(tx *gorm.DB) error {
+	if o.ID != "" {
+		return nil
+	}
+	id, err := NewID()
+	if err != nil {
+		return err
+	}
+	o.ID = id
+	return nil
+}
+
+// checkApproval menolak operasi yang melebihi ApprovalThresholds sesuai mata uang wallet
+// operasi yang dijalankan oleh ApproveOperation tidak diperiksa lagi
+func (s *WalletService) checkApproval(kind OperationKind, wallet *Wallet, amount int64) error {
+	threshold, ok := s.ApprovalThresholds[wallet.Currency]
+	if s.approved || !ok || threshold <= 0 || amount <= threshold {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s from wallet %s exceeds %s", ErrApprovalRequired, kind,
+		Money{Amount: amount, Currency: wallet.Currency}, wallet.ID, Money{Amount: threshold, Currency: wallet.Currency})
+}
+
+func requireActor(ctx context.Context) (string, error) {
+	actor, ok := ActorFromContext(ctx)
+	if !ok {
+		return "", ErrActorRequired
+	}
+	return actor, nil
+}
+
+// ProposeOperation mengajukan operasi saldo untuk disetujui user lain
+// actor dari context (WithActor) dicatat sebagai ProposedBy, dan pengajuan dicatat di user_logs
+func (s *WalletService) ProposeOperation(ctx context.Context, op *PendingOperation) error {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return err
+	}
+	if op.Amount <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidAmount, op.Amount)
+	}
+	walletIDs := []string{op.WalletId}
+	switch op.Kind {
+	case OperationTransfer:
+		if op.ToWalletId == nil {
+			return ErrMissingTargetWallet
+		}
+		if *op.ToWalletId == op.WalletId {
+			return ErrSameWallet
+		}
+		walletIDs = append(walletIDs, *op.ToWalletId)
+	case OperationDeposit, OperationWithdraw:
+		if op.ToWalletId != nil {
+			return fmt.Errorf("%w: %s", ErrUnexpectedTargetWallet, op.Kind)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidOperationKind, op.Kind)
+	}
+
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		wallets, err := lockWallets(tx, walletIDs...)
+		if err != nil {
+			return err
+		}
+		wallet := wallets[op.WalletId]
+
+		op.Currency = wallet.Currency
+		op.Status = OperationPending
+		op.ProposedBy = actor
+		op.DecidedBy = ""
+		op.DecidedAt = nil
+		if op.ExpiresAt.IsZero() {
+			op.ExpiresAt = time.Now().Add(DefaultApprovalTTL)
+		}
+		op.ExpiresAt = op.ExpiresAt.UTC()
+		if err := tx.Omit("Wallet", "ToWallet").Create(op).Error; err != nil {
+			return err
+		}
+
+		changes := map[string]AuditChange{
+			"kind":       {New: op.Kind},
+			"wallet_id":  {New: op.WalletId},
+			"amount":     {New: op.Amount},
+			"currency":   {New: op.Currency},
+			"status":     {New: op.Status},
+			"expires_at": {New: auditValue(op.ExpiresAt)},
+		}
+		if op.ToWalletId != nil {
+			changes["to_wallet_id"] = AuditChange{New: *op.ToWalletId}
+		}
+		return writeUserLog(tx, wallet.UserId, AuditPropose, op.TableName(), op.ID, changes)
+	})
+}
+
+// lockOperation mengunci PendingOperation dan memastikan masih bisa diputuskan oleh actor
+// operasi yang sudah kadaluarsa diubah menjadi expired, expired bernilai true agar pemanggil
+// tetap commit perubahan status tersebut lalu mengembalikan ErrOperationExpired
+func lockOperation(tx *gorm.DB, id, actor string) (op *PendingOperation, expired bool, err error) {
+	op = &PendingOperation{}
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(op, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, fmt.Errorf("%w: %s", ErrOperationNotFound, id)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if op.Status != OperationPending {
+		return nil, false, fmt.Errorf("%w: %s is %s", ErrOperationNotPending, op.ID, op.Status)
+	}
+	if !op.ExpiresAt.After(time.Now()) {
+		return op, true, expireOperation(tx, op)
+	}
+	if op.ProposedBy == actor {
+		return nil, false, fmt.Errorf("%w: %s proposed %s", ErrSameApprover, actor, op.ID)
+	}
+	return op, false, nil
+}
+
+// decideOperation menyimpan keputusan dan mencatatnya di user_logs
+func decideOperation(tx *gorm.DB, op *PendingOperation, status OperationStatus, action AuditAction, decidedBy, reason string) error {
+	now := time.Now().UTC()
+	err := tx.Model(op).Updates(map[string]interface{}{
+		"status":     status,
+		"decided_by": decidedBy,
+		"decided_at": now,
+		"reason":     reason,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var wallet Wallet
+	if err := tx.Select("user_id").Take(&wallet, "id = ?", op.WalletId).Error; err != nil {
+		return err
+	}
+	changes := map[string]AuditChange{"status": {Old: OperationPending, New: status}}
+	if reason != "" {
+		changes["reason"] = AuditChange{New: reason}
+	}
+	return writeUserLog(tx, wallet.UserId, action, op.TableName(), op.ID, changes)
+}
+
+func expireOperation(tx *gorm.DB, op *PendingOperation) error {
+	return decideOperation(tx, op, OperationExpired, AuditExpire, "", "")
+}
+
+// ApproveOperation menyetujui dan langsung menjalankan operasi dalam satu transaction
+// actor dari context harus berbeda dengan user yang mengajukan
+// jika operasinya gagal (misalnya saldo tidak cukup) persetujuan ikut dibatalkan dan operasi tetap pending
+func (s *WalletService) ApproveOperation(ctx context.Context, id string) error {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return err
+	}
+
+	var expired bool
+	err = s.transaction(ctx, func(tx *gorm.DB) error {
+		op, exp, err := lockOperation(tx, id, actor)
+		if expired = exp; err != nil || expired {
+			return err
+		}
+
+		approved := s.withDB(tx)
+		approved.approved = true
+		switch op.Kind {
+		case OperationTransfer:
+			err = approved.Transfer(ctx, op.WalletId, *op.ToWalletId, op.Amount)
+		case OperationDeposit:
+			err = approved.Deposit(ctx, op.WalletId, op.Amount, op.Reference)
+		case OperationWithdraw:
+			err = approved.Withdraw(ctx, op.WalletId, op.Amount, op.Reference)
+		default:
+			err = fmt.Errorf("%w: %q", ErrInvalidOperationKind, op.Kind)
+		}
+		if err != nil {
+			return err
+		}
+		return decideOperation(tx, op, OperationApproved, AuditApprove, actor, "")
+	})
+	if err == nil && expired {
+		return fmt.Errorf("%w: %s", ErrOperationExpired, id)
+	}
+	return err
+}
+
+// RejectOperation menolak operasi beserta alasannya, actor dari context harus berbeda dengan user yang mengajukan
+func (s *WalletService) RejectOperation(ctx context.Context, id, reason string) error {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrReasonRequired
+	}
+
+	var expired bool
+	err = s.transaction(ctx, func(tx *gorm.DB) error {
+		op, exp, err := lockOperation(tx, id, actor)
+		if expired = exp; err != nil || expired {
+			return err
+		}
+		return decideOperation(tx, op, OperationRejected, AuditReject, actor, reason)
+	})
+	if err == nil && expired {
+		return fmt.Errorf("%w: %s", ErrOperationExpired, id)
+	}
+	return err
+}
+
+// ExpirePendingOperations membatalkan semua operasi pending yang sudah melewati ExpiresAt
+// setiap pembatalan dicatat di user_logs, bisa dijalankan berkala seperti ReleaseExpiredHolds
+func (s *WalletService) ExpirePendingOperations(ctx context.Context) (int64, error) {
+	var expired int64
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		expired = 0
+		var ops []PendingOperation
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expires_at <= ?", OperationPending, time.Now().UTC()).
+			Order("id").
+			Find(&ops).Error
+		if err != nil {
+			return err
+		}
+		for i := range ops {
+			if err := expireOperation(tx, &ops[i]); err != nil {
+				return err
+			}
+			expired++
+		}
+		return nil
+	})
+	return expired, err
+}
@@ -0,0 +1,213 @@
+package belajargorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func operationLogs(t *testing.T, db *gorm.DB, id string) []UserLog {
+	t.Helper()
+	var logs []UserLog
+	assert.Nil(t, db.Where("entity = ? AND entity_id = ?", "pending_operations", id).Order("id").Find(&logs).Error)
+	return logs
+}
+
+func TestApprovalThreshold(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	service.ApprovalThresholds = map[string]int64{"IDR": 5000}
+	ctx := context.Background()
+
+	assert.ErrorIs(t, service.Transfer(ctx, "2", "3", 5001), ErrApprovalRequired)
+	assert.ErrorIs(t, service.Withdraw(ctx, "2", 5001, "atm"), ErrApprovalRequired)
+	assert.ErrorIs(t, service.Deposit(ctx, "2", 5001, "topup"), ErrApprovalRequired)
+	assert.Nil(t, service.Transfer(ctx, "2", "3", 5000))
+	assert.Equal(t, int64(5000), walletBalance(t, db, "2"))
+}
+
+func TestApprovalThresholdHold(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 10000)
+	service := NewWalletService(db)
+	service.ApprovalThresholds = map[string]int64{"IDR": 5000}
+	maker := WithActor(context.Background(), "2")
+	checker := WithActor(context.Background(), "1")
+
+	// Authorize lalu Capture tidak bisa melewati maker-checker
+	hold, err := service.Authorize(maker, "2", 8000, time.Hour)
+	assert.ErrorIs(t, err, ErrApprovalRequired)
+	assert.Nil(t, hold)
+	var holds int64
+	assert.Nil(t, db.Model(&WalletHold{}).Count(&holds).Error)
+	assert.Equal(t, int64(0), holds)
+
+	// debit di atas batas harus diajukan dan menjadi pending operation
+	op := PendingOperation{Kind: OperationWithdraw, WalletId: "2", Amount: 8000, Reference: "merchant"}
+	assert.Nil(t, service.ProposeOperation(maker, &op))
+	assert.Equal(t, OperationPending, op.Status)
+	assert.Equal(t, int64(10000), walletBalance(t, db, "2"))
+	assert.Nil(t, service.ApproveOperation(checker, op.ID))
+	assert.Equal(t, int64(2000), walletBalance(t, db, "2"))
+
+	// hold sampai batas tetap bisa langsung di-capture
+	hold, err = service.Authorize(maker, "2", 2000, time.Hour)
+	assert.Nil(t, err)
+	assert.Nil(t, service.Capture(maker, hold.ID, 2000))
+	assert.Equal(t, int64(0), walletBalance(t, db, "2"))
+}
+
+func TestApprovalThresholdReconcile(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 10000)
+	service := NewWalletService(db)
+	service.ApprovalThresholds = map[string]int64{"IDR": 5000}
+	ctx := WithActor(context.Background(), "1")
+
+	assert.Nil(t, db.Table("wallets").Where("id = ?", "1").Update("balance", 100).Error)
+	assert.Nil(t, db.Table("wallets").Where("id = ?", "2").Update("balance", 9000).Error)
+
+	report, err := service.Reconcile(ctx, ReconcileOptions{Fix: true})
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(report.Discrepancies)) {
+		// koreksi 9900 melebihi batas, koreksi 1000 tetap dijalankan
+		assert.True(t, report.Discrepancies[0].ApprovalRequired)
+		assert.True(t, report.Discrepancies[0].Outstanding())
+		assert.True(t, report.Discrepancies[1].Corrected)
+	}
+	assert.Equal(t, int64(100), walletBalance(t, db, "1"))
+	assert.Equal(t, int64(10000), walletBalance(t, db, "2"))
+}
+
+func TestApproveOperation(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	service.ApprovalThresholds = map[string]int64{"IDR": 5000}
+	maker := WithActor(context.Background(), "2")
+	checker := WithActor(context.Background(), "1")
+
+	to := "3"
+	op := PendingOperation{Kind: OperationTransfer, WalletId: "2", ToWalletId: &to, Amount: 8000}
+	assert.ErrorIs(t, service.ProposeOperation(context.Background(), &op), ErrActorRequired)
+	assert.Nil(t, service.ProposeOperation(maker, &op))
+	assert.Equal(t, OperationPending, op.Status)
+	assert.Equal(t, "2", op.ProposedBy)
+	assert.Equal(t, "IDR", op.Currency)
+	assert.Equal(t, int64(10000), walletBalance(t, db, "2"))
+
+	assert.ErrorIs(t, service.ApproveOperation(maker, op.ID), ErrSameApprover)
+	assert.Nil(t, service.ApproveOperation(checker, op.ID))
+	assert.Equal(t, int64(2000), walletBalance(t, db, "2"))
+	assert.Equal(t, int64(18000), walletBalance(t, db, "3"))
+	assert.ErrorIs(t, service.ApproveOperation(checker, op.ID), ErrOperationNotPending)
+
+	var saved PendingOperation
+	assert.Nil(t, db.Take(&saved, "id = ?", op.ID).Error)
+	assert.Equal(t, OperationApproved, saved.Status)
+	assert.Equal(t, "1", saved.DecidedBy)
+	assert.NotNil(t, saved.DecidedAt)
+
+	logs := operationLogs(t, db, op.ID)
+	assert.Equal(t, 2, len(logs))
+	assert.Equal(t, AuditPropose, logs[0].Action)
+	assert.Equal(t, "2", logs[0].ActorId)
+	assert.Equal(t, "2", logs[0].UserId)
+	assert.Equal(t, AuditApprove, logs[1].Action)
+	assert.Equal(t, "1", logs[1].ActorId)
+	assert.JSONEq(t, `{"status":{"old":"pending","new":"approved"}}`, logs[1].Changes)
+	assertLedgerConsistent(t, db)
+}
+
+func TestApproveOperationFailure(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	maker := WithActor(context.Background(), "2")
+	checker := WithActor(context.Background(), "1")
+
+	op := PendingOperation{Kind: OperationWithdraw, WalletId: "2", Amount: 8000, Reference: "cash out"}
+	assert.Nil(t, service.ProposeOperation(maker, &op))
+	assert.Nil(t, service.Withdraw(maker, "2", 5000, "atm"))
+
+	// saldo tidak cukup, persetujuan dibatalkan dan operasi tetap pending
+	assert.ErrorIs(t, service.ApproveOperation(checker, op.ID), ErrInsufficientBalance)
+	var saved PendingOperation
+	assert.Nil(t, db.Take(&saved, "id = ?", op.ID).Error)
+	assert.Equal(t, OperationPending, saved.Status)
+	assert.Equal(t, 1, len(operationLogs(t, db, op.ID)))
+
+	assert.ErrorIs(t, service.RejectOperation(checker, op.ID, " "), ErrReasonRequired)
+	assert.ErrorIs(t, service.RejectOperation(maker, op.ID, "batal"), ErrSameApprover)
+	assert.Nil(t, service.RejectOperation(checker, op.ID, "saldo tidak cukup"))
+	assert.Nil(t, db.Take(&saved, "id = ?", op.ID).Error)
+	assert.Equal(t, OperationRejected, saved.Status)
+	assert.Equal(t, "saldo tidak cukup", saved.Reason)
+
+	logs := operationLogs(t, db, op.ID)
+	assert.Equal(t, 2, len(logs))
+	assert.Equal(t, AuditReject, logs[1].Action)
+	assert.Equal(t, int64(5000), walletBalance(t, db, "2"))
+}
+
+func TestProposeOperationValidation(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	maker := WithActor(context.Background(), "2")
+
+	same, to := "2", "3"
+	assert.ErrorIs(t, service.ProposeOperation(maker, &PendingOperation{Kind: "refund", WalletId: "2", Amount: 1}), ErrInvalidOperationKind)
+	assert.ErrorIs(t, service.ProposeOperation(maker, &PendingOperation{Kind: OperationTransfer, WalletId: "2", Amount: 1}), ErrMissingTargetWallet)
+	assert.ErrorIs(t, service.ProposeOperation(maker, &PendingOperation{Kind: OperationTransfer, WalletId: "2", ToWalletId: &same, Amount: 1}), ErrSameWallet)
+	assert.ErrorIs(t, service.ProposeOperation(maker, &PendingOperation{Kind: OperationDeposit, WalletId: "2", ToWalletId: &to, Amount: 1}), ErrUnexpectedTargetWallet)
+	assert.ErrorIs(t, service.ProposeOperation(maker, &PendingOperation{Kind: OperationDeposit, WalletId: "2"}), ErrInvalidAmount)
+	assert.ErrorIs(t, service.ProposeOperation(maker, &PendingOperation{Kind: OperationDeposit, WalletId: "x", Amount: 1}), ErrWalletNotFound)
+	assert.ErrorIs(t, service.ApproveOperation(maker, "x"), ErrOperationNotFound)
+}
+
+func TestExpirePendingOperations(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	maker := WithActor(context.Background(), "2")
+	checker := WithActor(context.Background(), "1")
+
+	first := PendingOperation{Kind: OperationDeposit, WalletId: "2", Amount: 1000}
+	second := PendingOperation{Kind: OperationDeposit, WalletId: "3", Amount: 1000}
+	third := PendingOperation{Kind: OperationDeposit, WalletId: "3", Amount: 1000}
+	for _, op := range []*PendingOperation{&first, &second, &third} {
+		assert.Nil(t, service.ProposeOperation(maker, op))
+	}
+	past := time.Now().Add(-time.Minute).UTC()
+	assert.Nil(t, db.Model(&PendingOperation{}).Where("id IN ?", []string{first.ID, second.ID}).Update("expires_at", past).Error)
+
+	// operasi yang kadaluarsa tidak bisa disetujui, dan statusnya tetap berubah menjadi expired
+	assert.ErrorIs(t, service.ApproveOperation(checker, first.ID), ErrOperationExpired)
+	assert.Equal(t, int64(10000), walletBalance(t, db, "2"))
+
+	expired, err := service.ExpirePendingOperations(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	var statuses []OperationStatus
+	assert.Nil(t, db.Model(&PendingOperation{}).Order("id").Pluck("status", &statuses).Error)
+	assert.Equal(t, []OperationStatus{OperationExpired, OperationExpired, OperationPending}, statuses)
+
+	for _, id := range []string{first.ID, second.ID} {
+		logs := operationLogs(t, db, id)
+		assert.Equal(t, 2, len(logs))
+		assert.Equal(t, AuditExpire, logs[1].Action)
+	}
+}
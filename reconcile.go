@@ -0,0 +1,198 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AuditReconcile dicatat di user_logs saat Reconcile mengoreksi saldo wallet
+const AuditReconcile AuditAction = "reconcile"
+
+// BalanceDiscrepancy adalah wallet yang saldonya berbeda dengan hasil perhitungan wallet_entries
+type BalanceDiscrepancy struct {
+	WalletID string
+	UserID   string
+	Recorded Money
+	Expected Money
+	// Corrected bernilai true jika saldo sudah dikoreksi oleh Reconcile
+	Corrected bool
+	// Resolved bernilai true jika saldo ternyata sudah sama dengan ledger saat akan dikoreksi
+	Resolved bool
+	// ApprovalRequired bernilai true jika koreksi melebihi ApprovalThresholds sehingga tidak dijalankan
+	ApprovalRequired bool
+}
+
+// Outstanding bernilai true jika perbedaan saldo belum dikoreksi dan belum hilang dengan sendirinya
+func (d BalanceDiscrepancy) Outstanding() bool {
+	return !d.Corrected && !d.Resolved
+}
+
+// Difference adalah selisih saldo tercatat terhadap saldo seharusnya
+func (d BalanceDiscrepancy) Difference() Money {
+	return Money{Amount: d.Recorded.Amount - d.Expected.Amount, Currency: d.Recorded.Currency}
+}
+
+func (d BalanceDiscrepancy) String() string {
+	status := ""
+	switch {
+	case d.Corrected:
+		status = " (corrected)"
+	case d.Resolved:
+		status = " (resolved)"
+	case d.ApprovalRequired:
+		status = " (approval required)"
+	}
+	return fmt.Sprintf("wallet %s: recorded %s, expected %s, difference %s%s",
+		d.WalletID, d.Recorded, d.Expected, d.Difference().Decimal(), status)
+}
+
+// ReconciliationReport adalah hasil Reconcile
+type ReconciliationReport struct {
+	CheckedWallets   int
+	Discrepancies    []BalanceDiscrepancy
+	UnbalancedGroups []string
+}
+
+// HasDiscrepancy bernilai true jika ada saldo yang berbeda atau group ledger yang tidak seimbang
+func (r *ReconciliationReport) HasDiscrepancy() bool {
+	return len(r.Discrepancies) > 0 || len(r.UnbalancedGroups) > 0
+}
+
+func (r *ReconciliationReport) String() string {
+	if !r.HasDiscrepancy() {
+		return fmt.Sprintf("%d wallets reconciled, no discrepancy", r.CheckedWallets)
+	}
+	lines := []string{fmt.Sprintf("%d wallets checked, %d discrepancies", r.CheckedWallets, len(r.Discrepancies))}
+	for _, d := range r.Discrepancies {
+		lines = append(lines, d.String())
+	}
+	for _, group := range r.UnbalancedGroups {
+		lines = append(lines, fmt.Sprintf("ledger group %s does not balance", group))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ReconcileOptions mengatur Reconcile
+type ReconcileOptions struct {
+	// Fix mengoreksi wallets.balance sesuai wallet_entries dan mencatat koreksinya di user_logs
+	Fix bool
+}
+
+type reconcileRow struct {
+	ID       string
+	UserId   string
+	Currency string
+	Balance  int64
+	Expected int64
+}
+
+// Reconcile menghitung ulang saldo setiap wallet dari wallet_entries dan melaporkan saldo yang berbeda
+// saldo bisa berbeda jika kolom balance diubah di luar WalletService, misalnya UPDATE langsung di database
+//
+// dengan opts.Fix, setiap wallet yang berbeda dikunci lalu saldonya disamakan dengan ledger
+// dan koreksinya dicatat di user_logs dengan action AuditReconcile, actor diambil dari context (WithActor)
+// group ledger yang tidak seimbang hanya dilaporkan, karena tidak bisa diperbaiki secara otomatis
+// koreksi yang melebihi ApprovalThresholds tidak dijalankan dan ditandai ApprovalRequired
+func (s *WalletService) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconciliationReport, error) {
+	db := s.db.WithContext(ctx)
+	report := &ReconciliationReport{}
+
+	rows, err := db.Table("wallets").
+		Select("wallets.id, wallets.user_id, wallets.currency, wallets.balance, "+
+			"COALESCE(SUM(CASE WHEN wallet_entries.direction = ? THEN wallet_entries.amount ELSE -wallet_entries.amount END), 0) AS expected", Credit).
+		Joins("LEFT JOIN wallet_entries ON wallet_entries.account = wallets.id").
+		Group("wallets.id, wallets.user_id, wallets.currency, wallets.balance").
+		Order("wallets.id").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row reconcileRow
+		if err := db.ScanRows(rows, &row); err != nil {
+			return nil, err
+		}
+		report.CheckedWallets++
+		if row.Balance == row.Expected {
+			continue
+		}
+		report.Discrepancies = append(report.Discrepancies, BalanceDiscrepancy{
+			WalletID: row.ID,
+			UserID:   row.UserId,
+			Recorded: Money{Amount: row.Balance, Currency: row.Currency},
+			Expected: Money{Amount: row.Expected, Currency: row.Currency},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if report.UnbalancedGroups, err = UnbalancedGroups(ctx, s.db); err != nil {
+		return nil, err
+	}
+
+	if opts.Fix {
+		for i := range report.Discrepancies {
+			err := s.correctBalance(ctx, &report.Discrepancies[i])
+			if errors.Is(err, ErrApprovalRequired) {
+				report.Discrepancies[i].ApprovalRequired = true
+				continue
+			}
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, nil
+}
+
+// correctBalance menyamakan saldo wallet dengan ledger di dalam transaction
+// saldo dihitung ulang setelah wallet dikunci, karena bisa berubah sejak laporan dibuat
+// jika saldo sudah sama dengan ledger, d ditandai Resolved tanpa koreksi
+func (s *WalletService) correctBalance(ctx context.Context, d *BalanceDiscrepancy) error {
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		wallets, err := lockWallets(tx, d.WalletID)
+		if err != nil {
+			return err
+		}
+		wallet := wallets[d.WalletID]
+		expected, err := LedgerBalance(ctx, tx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		d.Recorded = wallet.BalanceMoney()
+		d.Expected = Money{Amount: expected, Currency: wallet.Currency}
+		if wallet.Balance == expected {
+			d.Resolved = true
+			return nil
+		}
+
+		// koreksi saldo adalah penyesuaian saldo, jadi diperiksa terhadap ApprovalThresholds
+		// seperti Deposit (saldo bertambah) atau Withdraw (saldo berkurang)
+		kind, amount := OperationDeposit, expected-wallet.Balance
+		if amount < 0 {
+			kind, amount = OperationWithdraw, -amount
+		}
+		if err := s.checkApproval(kind, wallet, amount); err != nil {
+			return err
+		}
+		if _, err := setWalletBalance(tx, wallet.ID, "", expected); err != nil {
+			return err
+		}
+		err = writeUserLog(tx, wallet.UserId, AuditReconcile, wallet.TableName(), wallet.ID, map[string]AuditChange{
+			"balance": {Old: wallet.Balance, New: expected},
+		})
+		if err != nil {
+			return err
+		}
+		d.Corrected = true
+		return nil
+	})
+}
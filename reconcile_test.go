@@ -0,0 +1,104 @@
+package belajargorm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 1000)
+	service := NewWalletService(db)
+	ctx := WithActor(context.Background(), "1")
+
+	report, err := service.Reconcile(ctx, ReconcileOptions{})
+	assert.Nil(t, err)
+	assert.False(t, report.HasDiscrepancy())
+	assert.Equal(t, 3, report.CheckedWallets)
+
+	// saldo diubah langsung di database, di luar WalletService
+	assert.Nil(t, db.Table("wallets").Where("id = ?", "2").Update("balance", 999999).Error)
+	assert.Nil(t, db.Exec("UPDATE wallets SET balance = balance - 1 WHERE id = ?", "3").Error)
+
+	report, err = service.Reconcile(ctx, ReconcileOptions{})
+	assert.Nil(t, err)
+	assert.True(t, report.HasDiscrepancy())
+	assert.Equal(t, 2, len(report.Discrepancies))
+	assert.Equal(t, "2", report.Discrepancies[0].WalletID)
+	assert.Equal(t, "2", report.Discrepancies[0].UserID)
+	assert.Equal(t, Money{Amount: 999999, Currency: "IDR"}, report.Discrepancies[0].Recorded)
+	assert.Equal(t, Money{Amount: 1000, Currency: "IDR"}, report.Discrepancies[0].Expected)
+	assert.Equal(t, int64(-1), report.Discrepancies[1].Difference().Amount)
+	assert.False(t, report.Discrepancies[0].Corrected)
+	assert.Equal(t, int64(999999), walletBalance(t, db, "2"))
+
+	report, err = service.Reconcile(ctx, ReconcileOptions{Fix: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(report.Discrepancies))
+	assert.True(t, report.Discrepancies[0].Corrected)
+	assert.True(t, report.Discrepancies[1].Corrected)
+	assert.Equal(t, int64(1000), walletBalance(t, db, "2"))
+	assert.Equal(t, int64(1000), walletBalance(t, db, "3"))
+
+	var logs []UserLog
+	assert.Nil(t, db.Where("action = ?", AuditReconcile).Order("entity_id").Find(&logs).Error)
+	assert.Equal(t, 2, len(logs))
+	assert.Equal(t, "wallets", logs[0].Entity)
+	assert.Equal(t, "2", logs[0].EntityId)
+	assert.Equal(t, "2", logs[0].UserId)
+	assert.Equal(t, "1", logs[0].ActorId)
+	var changes map[string]AuditChange
+	assert.Nil(t, json.Unmarshal([]byte(logs[0].Changes), &changes))
+	assert.Equal(t, float64(999999), changes["balance"].Old)
+	assert.Equal(t, float64(1000), changes["balance"].New)
+
+	report, err = service.Reconcile(ctx, ReconcileOptions{})
+	assert.Nil(t, err)
+	assert.False(t, report.HasDiscrepancy())
+	assertLedgerConsistent(t, db)
+}
+
+func TestReconcileAlreadyResolved(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 1, 1000)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	// perbedaan yang dilaporkan sudah hilang saat Fix dijalankan, misalnya dikoreksi proses lain
+	d := BalanceDiscrepancy{
+		WalletID: "1",
+		Recorded: Money{Amount: 999, Currency: "IDR"},
+		Expected: Money{Amount: 1000, Currency: "IDR"},
+	}
+	assert.Nil(t, service.correctBalance(ctx, &d))
+	assert.False(t, d.Corrected)
+	assert.True(t, d.Resolved)
+	assert.False(t, d.Outstanding())
+	assert.Equal(t, d.Expected, d.Recorded)
+	assert.Contains(t, d.String(), "(resolved)")
+
+	var count int64
+	assert.Nil(t, db.Model(&UserLog{}).Where("action = ?", AuditReconcile).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestReconcileUnbalancedGroup(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewWalletService(db)
+
+	// entry yang ditulis langsung tanpa pasangan membuat group tidak seimbang
+	err := db.Create(&WalletEntry{ID: "x", GroupId: "g", Account: AccountExternal, Direction: Credit, Amount: 5, Currency: "IDR"}).Error
+	assert.Nil(t, err)
+
+	report, err := service.Reconcile(context.Background(), ReconcileOptions{Fix: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"g"}, report.UnbalancedGroups)
+	assert.Empty(t, report.Discrepancies)
+	assert.Contains(t, report.String(), "ledger group g does not balance")
+}
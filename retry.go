@@ -0,0 +1,64 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// jumlah percobaan transaction default untuk WalletService dan Idempotent
+const defaultMaxAttempts = 5
+
+// isRetryable bernilai true untuk error yang hilang jika transaction diulang
+// seperti deadlock, serialization failure dan database yang sedang terkunci
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 40001 serialization_failure, 40P01 deadlock_detected
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// 1213 deadlock, 1205 lock wait timeout
+		return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// transaction menjalankan fc di dalam transaction dan mengulanginya jika gagal karena isRetryable
+// jika db sudah berada di dalam transaction, fc hanya dijalankan sekali karena
+// transaction luar yang harus diulang
+func transaction(ctx context.Context, db *gorm.DB, maxAttempts int, fc func(tx *gorm.DB) error) error {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		maxAttempts = 1
+	}
+
+	backoff := 10 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := db.WithContext(ctx).Transaction(fc)
+		if err == nil || attempt >= maxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,176 @@
+package belajargorm
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("belajargorm: invalid schedule")
+
+// Schedule menghitung waktu jalan berikutnya dari jadwal berulang
+// Next mengembalikan zero time jika jadwal tidak pernah jalan lagi
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule membaca jadwal dalam salah satu format berikut
+//
+//	"0 9 1 * *"   cron 5 kolom: menit jam tanggal bulan hari (0 atau 7 = minggu)
+//	              setiap kolom boleh berisi *, angka, range a-b, step */n atau a-b/n, dan daftar dipisah koma
+//	"@monthly"    singkatan cron: @yearly, @monthly, @weekly, @daily dan @hourly
+//	"@every 36h"  interval tetap sejak jalan sebelumnya, minimal satu menit
+//
+// jadwal cron dihitung menurut loc, nil berarti UTC
+func ParseSchedule(spec string, loc *time.Location) (Schedule, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	spec = strings.TrimSpace(spec)
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidSchedule, spec, err)
+		}
+		if interval < time.Minute {
+			return nil, fmt.Errorf("%w: %q: interval must be at least 1m", ErrInvalidSchedule, spec)
+		}
+		return intervalSchedule(interval), nil
+	}
+	if cron, ok := scheduleDescriptors[spec]; ok {
+		spec = cron
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q: expected 5 fields", ErrInvalidSchedule, spec)
+	}
+	s := &cronSchedule{loc: loc}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("%w: %q: minute %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("%w: %q: hour %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("%w: %q: day of month %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("%w: %q: month %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("%w: %q: day of week %v", ErrInvalidSchedule, spec, err)
+	}
+	// 7 dan 0 sama-sama minggu
+	if hasBit(s.dow, 7) {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+type intervalSchedule time.Duration
+
+func (i intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+// cronSchedule menyimpan nilai yang cocok untuk setiap kolom sebagai bitmask
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+	loc                           *time.Location
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			first, last, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(first); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(last); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func hasBit(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+// jika tanggal dan hari sama-sama dibatasi, cukup salah satu yang cocok seperti cron pada umumnya
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := hasBit(c.dom, t.Day()), hasBit(c.dow, int(t.Weekday()))
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next mencari menit berikutnya yang cocok, paling jauh 5 tahun ke depan
+func (c *cronSchedule) Next(after time.Time) time.Time {
+	t := after.In(c.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, c.loc).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !hasBit(c.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+		case !hasBit(c.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+		case !hasBit(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,46 @@
+package belajargorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	t.Parallel()
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	assert.Nil(t, err)
+
+	cases := []struct {
+		spec  string
+		loc   *time.Location
+		after time.Time
+		next  time.Time
+	}{
+		{"0 0 1 * *", jakarta, time.Date(2026, 1, 15, 10, 0, 0, 0, jakarta), time.Date(2026, 2, 1, 0, 0, 0, 0, jakarta)},
+		{"@monthly", jakarta, time.Date(2026, 2, 1, 0, 0, 0, 0, jakarta), time.Date(2026, 3, 1, 0, 0, 0, 0, jakarta)},
+		// jumat 17:50 ke senin 09:00
+		{"*/15 9-17 * * 1-5", nil, time.Date(2026, 10, 16, 17, 50, 0, 0, time.UTC), time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", nil, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)},
+		// tanggal 13 atau hari jumat
+		{"0 0 13 * 5", nil, time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", nil, time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		// 7 adalah minggu
+		{"30 8 * * 7", nil, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 8, 30, 0, 0, time.UTC)},
+		{"5,10 */6 * 1,6 *", nil, time.Date(2026, 1, 31, 18, 10, 0, 0, time.UTC), time.Date(2026, 6, 1, 0, 5, 0, 0, time.UTC)},
+		{"@every 36h", nil, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)},
+		// 30 februari tidak pernah ada
+		{"0 0 30 2 *", nil, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, c := range cases {
+		schedule, err := ParseSchedule(c.spec, c.loc)
+		assert.Nil(t, err, c.spec)
+		assert.True(t, c.next.Equal(schedule.Next(c.after)), "%s: expected %s, got %s", c.spec, c.next, schedule.Next(c.after))
+	}
+
+	for _, spec := range []string{"", "* * *", "61 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@every 10s", "@every soon"} {
+		_, err := ParseSchedule(spec, nil)
+		assert.ErrorIs(t, err, ErrInvalidSchedule, spec)
+	}
+}
@@ -0,0 +1,372 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduledTransferStatus adalah status ScheduledTransfer
+// jadwal failed adalah jadwal yang Schedule atau TimeZone-nya tidak bisa dihitung lagi
+type ScheduledTransferStatus string
+
+const (
+	ScheduleActive    ScheduledTransferStatus = "active"
+	ScheduleCompleted ScheduledTransferStatus = "completed"
+	ScheduleCancelled ScheduledTransferStatus = "cancelled"
+	ScheduleFailed    ScheduledTransferStatus = "failed"
+)
+
+// AuditCancel dicatat di user_logs saat transfer berulang dibatalkan
+const AuditCancel AuditAction = "cancel"
+
+// ScheduledTransferRunStatus adalah hasil satu kali eksekusi ScheduledTransfer
+type ScheduledTransferRunStatus string
+
+const (
+	RunSucceeded ScheduledTransferRunStatus = "succeeded"
+	RunFailed    ScheduledTransferRunStatus = "failed"
+)
+
+var (
+	ErrScheduledTransferNotFound = errors.New("belajargorm: scheduled transfer not found")
+	ErrNotScheduleOwner          = errors.New("belajargorm: scheduled transfer belongs to another user")
+)
+
+// ScheduledTransfer adalah transfer berulang (standing order), contoh setiap tanggal 1
+//
+//	ScheduledTransfer{FromWalletId: "1", ToWalletId: "2", Amount: 100000, Schedule: "0 0 1 * *", TimeZone: "Asia/Jakarta"}
+//
+// Schedule memakai format ParseSchedule dan dihitung menurut TimeZone
+// NextRunAt adalah jadwal yang belum dijalankan, EndAt opsional batas akhir jadwal
+type ScheduledTransfer struct {
+	ID           string                  `gorm:"primaryKey;column:id;size:100"`
+	FromWalletId string                  `gorm:"column:from_wallet_id;size:100;not null;index"`
+	ToWalletId   string                  `gorm:"column:to_wallet_id;size:100;not null"`
+	Amount       int64                   `gorm:"column:amount;not null"`
+	Schedule     string                  `gorm:"column:schedule;size:100;not null"`
+	TimeZone     string                  `gorm:"column:time_zone;size:64;not null"`
+	Status       ScheduledTransferStatus `gorm:"column:status;size:20;not null;index:idx_scheduled_transfers_due,priority:1"`
+	NextRunAt    time.Time               `gorm:"column:next_run_at;not null;index:idx_scheduled_transfers_due,priority:2"`
+	EndAt        *time.Time              `gorm:"column:end_at"`
+	CreatedAt    time.Time               `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time               `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	FromWallet   *Wallet                 `gorm:"foreignKey:from_wallet_id;references:id"`
+	ToWallet     *Wallet                 `gorm:"foreignKey:to_wallet_id;references:id"`
+	Runs         []ScheduledTransferRun  `gorm:"foreignKey:schedule_id;references:id"`
+}
+
+func (t *ScheduledTransfer) TableName() string {
+	return "scheduled_transfers"
+}
+
+// hook BeforeCreate mengisi ID, TimeZone dan Status jika masih kosong
+// waktu disimpan dalam UTC agar bisa dibandingkan sebagai string di sqlite
+func (t *ScheduledTransfer) BeforeCreate(tx *gorm.DB) error {
+	if t.TimeZone == "" {
+		t.TimeZone = "UTC"
+	}
+	if t.Status == "" {
+		t.Status = ScheduleActive
+	}
+	t.NextRunAt = t.NextRunAt.UTC()
+	if t.EndAt != nil {
+		end := t.EndAt.UTC()
+		t.EndAt = &end
+	}
+	if t.ID != "" {
+		return nil
+	}
+	id, err := NewID()
+	if err != nil {
+		return err
+	}
+	t.ID = id
+	return nil
+}
+
+// ParsedSchedule mengembalikan Schedule beserta zona waktunya
+func (t *ScheduledTransfer) ParsedSchedule() (Schedule, error) {
+	loc := time.UTC
+	if t.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(t.TimeZone); err != nil {
+			return nil, fmt.Errorf("%w: time zone %q: %v", ErrInvalidSchedule, t.TimeZone, err)
+		}
+	}
+	return ParseSchedule(t.Schedule, loc)
+}
+
+// ScheduledTransferRun mencatat hasil setiap eksekusi ScheduledTransfer
+// unique (schedule_id, scheduled_for) menjamin satu jadwal hanya dieksekusi sekali
+type ScheduledTransferRun struct {
+	ID           int64                      `gorm:"primaryKey;column:id;autoIncrement"`
+	ScheduleId   string                     `gorm:"column:schedule_id;size:100;not null;uniqueIndex:idx_scheduled_transfer_runs_occurrence,priority:1"`
+	ScheduledFor time.Time                  `gorm:"column:scheduled_for;not null;uniqueIndex:idx_scheduled_transfer_runs_occurrence,priority:2"`
+	Status       ScheduledTransferRunStatus `gorm:"column:status;size:20;not null"`
+	Error        string                     `gorm:"column:error;size:255"`
+	CreatedAt    time.Time                  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (r *ScheduledTransferRun) TableName() string {
+	return "scheduled_transfer_runs"
+}
+
+// ScheduleTransfer menyimpan transfer berulang baru, actor dari context (WithActor) harus pemilik wallet asal
+// jika NextRunAt kosong, jadwal pertama dihitung dari waktu sekarang
+func (s *WalletService) ScheduleTransfer(ctx context.Context, transfer *ScheduledTransfer) error {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return err
+	}
+	if transfer.Amount <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidAmount, transfer.Amount)
+	}
+	if transfer.FromWalletId == transfer.ToWalletId {
+		return ErrSameWallet
+	}
+	schedule, err := transfer.ParsedSchedule()
+	if err != nil {
+		return err
+	}
+	if transfer.NextRunAt.IsZero() {
+		transfer.NextRunAt = schedule.Next(time.Now())
+	}
+	if transfer.NextRunAt.IsZero() {
+		return fmt.Errorf("%w: %q never runs", ErrInvalidSchedule, transfer.Schedule)
+	}
+
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		wallets, err := lockWallets(tx, transfer.FromWalletId, transfer.ToWalletId)
+		if err != nil {
+			return err
+		}
+		if wallets[transfer.FromWalletId].UserId != actor {
+			return fmt.Errorf("%w: wallet %s", ErrNotScheduleOwner, transfer.FromWalletId)
+		}
+		return tx.Omit("FromWallet", "ToWallet", "Runs").Create(transfer).Error
+	})
+}
+
+// CancelScheduledTransfer menghentikan transfer berulang, actor dari context (WithActor) harus pemilik wallet asal
+// pembatalan dicatat di user_logs, dan menunggu jadwal yang sedang dieksekusi selesai
+func (s *WalletService) CancelScheduledTransfer(ctx context.Context, id string) error {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		var transfer ScheduledTransfer
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", id, ScheduleActive).
+			Take(&transfer).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %s", ErrScheduledTransferNotFound, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		var wallet Wallet
+		if err := tx.Select("user_id").Take(&wallet, "id = ?", transfer.FromWalletId).Error; err != nil {
+			return err
+		}
+		if wallet.UserId != actor {
+			return fmt.Errorf("%w: %s", ErrNotScheduleOwner, id)
+		}
+		if err := tx.Model(&transfer).Update("status", ScheduleCancelled).Error; err != nil {
+			return err
+		}
+		changes := map[string]AuditChange{"status": {Old: ScheduleActive, New: ScheduleCancelled}}
+		return writeUserLog(tx, wallet.UserId, AuditCancel, transfer.TableName(), transfer.ID, changes)
+	})
+}
+
+// RunScheduledTransfers menjalankan semua transfer berulang yang sudah jatuh tempo sampai tidak ada lagi
+// dan mengembalikan catatan eksekusinya, aman dijalankan oleh beberapa worker sekaligus
+//
+// setiap jadwal diambil di transaction sendiri dengan SELECT ... FOR UPDATE SKIP LOCKED,
+// sehingga worker lain melewati jadwal yang sedang dieksekusi (sqlite mengunci seluruh database)
+// transfer dijalankan di savepoint, jika ditolak (misalnya saldo tidak cukup atau melewati batas)
+// hanya transfernya yang dibatalkan lalu eksekusi dicatat sebagai RunFailed
+//
+// jika eksekusi dibatalkan karena error database, jadwal tersebut dicatat sebagai RunFailed dan dimajukan
+// di transaction sendiri agar tidak menghalangi jadwal lain, error-nya dikembalikan setelah semua jadwal dijalankan
+// kecuali error sementara (isRetryable) yang tetap gagal setelah MaxAttempts, jadwalnya tidak dimajukan
+// dan error langsung dikembalikan agar dijalankan lagi oleh pemanggilan berikutnya
+// jadwal yang terlewat, misalnya karena worker mati, digabung menjadi satu eksekusi untuk jadwal terakhir
+func (s *WalletService) RunScheduledTransfers(ctx context.Context) ([]ScheduledTransferRun, error) {
+	var runs []ScheduledTransferRun
+	var errs []error
+	for {
+		if err := ctx.Err(); err != nil {
+			return runs, errors.Join(append(errs, err)...)
+		}
+		run, id, err := s.runNextScheduledTransfer(ctx)
+		if err != nil && id != "" && ctx.Err() == nil && !isRetryable(err) {
+			errs = append(errs, fmt.Errorf("belajargorm: scheduled transfer %s: %w", id, err))
+			if run, err = s.failScheduledTransfer(ctx, id, err); err == nil {
+				if run != nil {
+					runs = append(runs, *run)
+				}
+				continue
+			}
+		}
+		if err != nil {
+			return runs, errors.Join(append(errs, err)...)
+		}
+		if run == nil {
+			return runs, errors.Join(errs...)
+		}
+		runs = append(runs, *run)
+	}
+}
+
+// runNextScheduledTransfer menjalankan satu jadwal yang jatuh tempo
+// id adalah jadwal yang sedang dieksekusi saat error terjadi, kosong jika belum ada jadwal yang diambil
+func (s *WalletService) runNextScheduledTransfer(ctx context.Context) (run *ScheduledTransferRun, id string, err error) {
+	err = s.transaction(ctx, func(tx *gorm.DB) error {
+		run, id = nil, ""
+		now := time.Now().UTC()
+
+		transfer, err := claimScheduledTransfer(tx, "", now)
+		if err != nil || transfer == nil {
+			return err
+		}
+		id = transfer.ID
+		schedule, err := transfer.ParsedSchedule()
+		if err != nil {
+			// jadwal tidak bisa dihitung lagi, dihentikan agar tidak diambil terus
+			run = &ScheduledTransferRun{
+				ScheduleId:   transfer.ID,
+				ScheduledFor: transfer.NextRunAt.UTC(),
+				Status:       RunFailed,
+				Error:        truncate(err.Error(), 255),
+			}
+			if err := tx.Create(run).Error; err != nil {
+				return err
+			}
+			return tx.Model(transfer).Update("status", ScheduleFailed).Error
+		}
+
+		run = &ScheduledTransferRun{
+			ScheduleId:   transfer.ID,
+			ScheduledFor: dueOccurrence(transfer, schedule, now),
+			Status:       RunSucceeded,
+		}
+		if err := s.withDB(tx).Transfer(ctx, transfer.FromWalletId, transfer.ToWalletId, transfer.Amount); err != nil {
+			if !isTransferRejection(err) {
+				return err
+			}
+			run.Status = RunFailed
+			run.Error = truncate(err.Error(), 255)
+		}
+		return finishScheduledTransfer(tx, transfer, schedule, run)
+	})
+	return run, id, err
+}
+
+// failScheduledTransfer mencatat jadwal id sebagai RunFailed karena cause lalu memajukannya
+// run bernilai nil jika jadwal sudah tidak jatuh tempo, misalnya sudah dieksekusi worker lain
+func (s *WalletService) failScheduledTransfer(ctx context.Context, id string, cause error) (*ScheduledTransferRun, error) {
+	var run *ScheduledTransferRun
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		run = nil
+		now := time.Now().UTC()
+
+		transfer, err := claimScheduledTransfer(tx, id, now)
+		if err != nil || transfer == nil {
+			return err
+		}
+		schedule, err := transfer.ParsedSchedule()
+		if err != nil {
+			return err
+		}
+		run = &ScheduledTransferRun{
+			ScheduleId:   transfer.ID,
+			ScheduledFor: dueOccurrence(transfer, schedule, now),
+			Status:       RunFailed,
+			Error:        truncate(cause.Error(), 255),
+		}
+		return finishScheduledTransfer(tx, transfer, schedule, run)
+	})
+	return run, err
+}
+
+// claimScheduledTransfer mengunci jadwal aktif yang sudah jatuh tempo paling awal, id kosong berarti jadwal mana saja
+// hasilnya nil jika tidak ada jadwal yang bisa diambil
+func claimScheduledTransfer(tx *gorm.DB, id string, now time.Time) (*ScheduledTransfer, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_run_at <= ?", ScheduleActive, now)
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	var transfer ScheduledTransfer
+	err := query.Order("next_run_at, id").Take(&transfer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// dueOccurrence mengembalikan jadwal terakhir yang sudah jatuh tempo dan belum melewati EndAt
+// jadwal terlewat sebelumnya dilewati sehingga transfer tidak dijalankan berkali-kali sekaligus
+func dueOccurrence(transfer *ScheduledTransfer, schedule Schedule, now time.Time) time.Time {
+	occurrence := transfer.NextRunAt.UTC()
+	for {
+		next := schedule.Next(occurrence)
+		if next.IsZero() || next.After(now) || (transfer.EndAt != nil && next.After(*transfer.EndAt)) {
+			return occurrence
+		}
+		occurrence = next.UTC()
+	}
+}
+
+// finishScheduledTransfer menyimpan run lalu memajukan NextRunAt ke jadwal setelah run.ScheduledFor,
+// atau menyelesaikan jadwal jika sudah tidak ada jadwal berikutnya sebelum EndAt
+func finishScheduledTransfer(tx *gorm.DB, transfer *ScheduledTransfer, schedule Schedule, run *ScheduledTransferRun) error {
+	if err := tx.Create(run).Error; err != nil {
+		return err
+	}
+	next := schedule.Next(run.ScheduledFor)
+	updates := map[string]interface{}{"next_run_at": next.UTC()}
+	if next.IsZero() || (transfer.EndAt != nil && next.After(*transfer.EndAt)) {
+		updates["next_run_at"] = run.ScheduledFor
+		updates["status"] = ScheduleCompleted
+	}
+	return tx.Model(transfer).Updates(updates).Error
+}
+
+// isTransferRejection bernilai true untuk error karena aturan bisnis, bukan karena database
+// error database membatalkan seluruh eksekusi, lalu jadwalnya dicatat gagal oleh failScheduledTransfer
+func isTransferRejection(err error) bool {
+	for _, target := range []error{
+		ErrInsufficientBalance, ErrLimitExceeded, ErrWalletFrozen, ErrWalletClosed,
+		ErrWalletNotFound, ErrExchangeRateNotFound, ErrInvalidAmount, ErrApprovalRequired,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate memotong s menjadi paling banyak n byte tanpa memotong karakter UTF-8
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
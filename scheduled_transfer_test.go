@@ -0,0 +1,265 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestScheduledTransfer(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	// dua jadwal terlewat, -36 jam dan -12 jam
+	first := time.Now().Add(-36 * time.Hour).Truncate(time.Second)
+	transfer := ScheduledTransfer{FromWalletId: "2", ToWalletId: "3", Amount: 1000, Schedule: "@every 24h", NextRunAt: first}
+	assert.Nil(t, service.ScheduleTransfer(WithActor(ctx, "2"), &transfer))
+	assert.Equal(t, ScheduleActive, transfer.Status)
+	assert.Equal(t, "UTC", transfer.TimeZone)
+
+	// jadwal terlewat digabung menjadi satu eksekusi untuk jadwal terakhir
+	runs, err := service.RunScheduledTransfers(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(runs))
+	assert.True(t, first.Add(24*time.Hour).Equal(runs[0].ScheduledFor))
+	assert.Equal(t, RunSucceeded, runs[0].Status)
+	assert.Equal(t, int64(9000), walletBalance(t, db, "2"))
+	assert.Equal(t, int64(11000), walletBalance(t, db, "3"))
+
+	var saved ScheduledTransfer
+	assert.Nil(t, db.Preload("Runs").Take(&saved, "id = ?", transfer.ID).Error)
+	assert.True(t, first.Add(48*time.Hour).Equal(saved.NextRunAt))
+	assert.Equal(t, 1, len(saved.Runs))
+
+	// belum jatuh tempo
+	runs, err = service.RunScheduledTransfers(ctx)
+	assert.Nil(t, err)
+	assert.Empty(t, runs)
+
+	// hanya pemilik wallet asal yang boleh membatalkan
+	assert.ErrorIs(t, service.CancelScheduledTransfer(ctx, transfer.ID), ErrActorRequired)
+	assert.ErrorIs(t, service.CancelScheduledTransfer(WithActor(ctx, "3"), transfer.ID), ErrNotScheduleOwner)
+	assert.Nil(t, service.CancelScheduledTransfer(WithActor(ctx, "2"), transfer.ID))
+	assert.ErrorIs(t, service.CancelScheduledTransfer(WithActor(ctx, "2"), transfer.ID), ErrScheduledTransferNotFound)
+
+	var logs int64
+	assert.Nil(t, db.Model(&UserLog{}).Where("action = ? AND entity_id = ?", AuditCancel, transfer.ID).Count(&logs).Error)
+	assert.Equal(t, int64(1), logs)
+	assertLedgerConsistent(t, db)
+}
+
+func TestScheduledTransferFailureAndEnd(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	start := time.Now().Add(-30 * time.Minute)
+	end := start.Add(time.Hour)
+	transfer := ScheduledTransfer{FromWalletId: "2", ToWalletId: "3", Amount: 6000, Schedule: "@every 1h", NextRunAt: start, EndAt: &end}
+	assert.Nil(t, service.ScheduleTransfer(WithActor(ctx, "2"), &transfer))
+
+	runs, err := service.RunScheduledTransfers(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(runs))
+	assert.Equal(t, RunSucceeded, runs[0].Status)
+	assert.Equal(t, int64(4000), walletBalance(t, db, "2"))
+
+	// jadwal kedua dimajukan agar jatuh tempo, ditolak karena saldo tidak cukup
+	// lalu jadwal selesai karena jadwal berikutnya melewati EndAt
+	assert.Nil(t, db.Model(&transfer).Update("next_run_at", time.Now().Add(-time.Minute).UTC()).Error)
+	runs, err = service.RunScheduledTransfers(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(runs))
+	assert.Equal(t, RunFailed, runs[0].Status)
+	assert.Contains(t, runs[0].Error, "insufficient balance")
+	assert.Equal(t, int64(4000), walletBalance(t, db, "2"))
+
+	var saved ScheduledTransfer
+	assert.Nil(t, db.Take(&saved, "id = ?", transfer.ID).Error)
+	assert.Equal(t, ScheduleCompleted, saved.Status)
+	assertLedgerConsistent(t, db)
+}
+
+func TestScheduledTransferValidation(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	ctx := WithActor(context.Background(), "2")
+
+	assert.ErrorIs(t, service.ScheduleTransfer(ctx, &ScheduledTransfer{FromWalletId: "2", ToWalletId: "3", Amount: 1, Schedule: "tiap bulan"}), ErrInvalidSchedule)
+	assert.ErrorIs(t, service.ScheduleTransfer(ctx, &ScheduledTransfer{FromWalletId: "2", ToWalletId: "3", Amount: 1, Schedule: "@daily", TimeZone: "Mars/Olympus"}), ErrInvalidSchedule)
+	assert.ErrorIs(t, service.ScheduleTransfer(ctx, &ScheduledTransfer{FromWalletId: "2", ToWalletId: "2", Amount: 1, Schedule: "@daily"}), ErrSameWallet)
+	assert.ErrorIs(t, service.ScheduleTransfer(ctx, &ScheduledTransfer{FromWalletId: "2", ToWalletId: "x", Amount: 1, Schedule: "@daily"}), ErrWalletNotFound)
+	assert.ErrorIs(t, service.ScheduleTransfer(ctx, &ScheduledTransfer{FromWalletId: "2", ToWalletId: "3", Schedule: "@daily"}), ErrInvalidAmount)
+
+	// hanya pemilik wallet asal yang boleh membuat jadwal
+	valid := ScheduledTransfer{FromWalletId: "3", ToWalletId: "2", Amount: 1, Schedule: "@daily"}
+	assert.ErrorIs(t, service.ScheduleTransfer(context.Background(), &valid), ErrActorRequired)
+	assert.ErrorIs(t, service.ScheduleTransfer(ctx, &valid), ErrNotScheduleOwner)
+	var count int64
+	assert.Nil(t, db.Model(&ScheduledTransfer{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	// jadwal pertama dihitung dari sekarang di zona waktu jadwal
+	transfer := ScheduledTransfer{FromWalletId: "2", ToWalletId: "3", Amount: 1, Schedule: "@monthly", TimeZone: "Asia/Jakarta"}
+	assert.Nil(t, service.ScheduleTransfer(ctx, &transfer))
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	next := transfer.NextRunAt.In(jakarta)
+	assert.Equal(t, 1, next.Day())
+	assert.Equal(t, 0, next.Hour())
+	assert.True(t, next.After(time.Now()))
+}
+
+func TestScheduledTransferConcurrentWorkers(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 5, 100000)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	start := time.Now().Add(-5*time.Hour + time.Minute)
+	for i := 2; i <= 5; i++ {
+		to := strconv.Itoa(i%4 + 2)
+		transfer := ScheduledTransfer{FromWalletId: strconv.Itoa(i), ToWalletId: to, Amount: 100, Schedule: "@every 1h", NextRunAt: start}
+		assert.Nil(t, service.ScheduleTransfer(WithActor(ctx, strconv.Itoa(i)), &transfer))
+	}
+
+	var wg sync.WaitGroup
+	counts := make([]int, 4)
+	for w := range counts {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			runs, err := service.RunScheduledTransfers(ctx)
+			assert.Nil(t, err)
+			counts[w] = len(runs)
+		}(w)
+	}
+	wg.Wait()
+
+	// 4 jadwal x 5 jam terlewat, setiap jadwal hanya dieksekusi sekali untuk jadwal terakhir
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	assert.Equal(t, 4, total)
+	var runs int64
+	assert.Nil(t, db.Model(&ScheduledTransferRun{}).Where("status = ?", RunSucceeded).Count(&runs).Error)
+	assert.Equal(t, int64(4), runs)
+	assertLedgerConsistent(t, db)
+}
+
+func TestScheduledTransferDatabaseError(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 5, 10000)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	// ledger gagal ditulis untuk wallet 4, misalnya karena disk penuh
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_entries", func(tx *gorm.DB) {
+		if rows, ok := tx.Statement.Dest.(*[]WalletEntry); ok {
+			for _, row := range *rows {
+				if row.Account == "4" {
+					tx.AddError(errors.New("disk full"))
+					return
+				}
+			}
+		}
+	})
+	assert.Nil(t, err)
+
+	broken := ScheduledTransfer{FromWalletId: "4", ToWalletId: "5", Amount: 1000, Schedule: "@every 1h", NextRunAt: time.Now().Add(-2 * time.Hour)}
+	assert.Nil(t, service.ScheduleTransfer(WithActor(ctx, "4"), &broken))
+	healthy := ScheduledTransfer{FromWalletId: "2", ToWalletId: "3", Amount: 1000, Schedule: "@every 1h", NextRunAt: time.Now().Add(-30 * time.Minute)}
+	assert.Nil(t, service.ScheduleTransfer(WithActor(ctx, "2"), &healthy))
+	invalid := ScheduledTransfer{FromWalletId: "3", ToWalletId: "2", Amount: 1000, Schedule: "@every 1h", NextRunAt: time.Now().Add(-time.Hour)}
+	assert.Nil(t, service.ScheduleTransfer(WithActor(ctx, "3"), &invalid))
+	assert.Nil(t, db.Model(&invalid).Update("time_zone", "Mars/Olympus").Error)
+
+	// jadwal yang gagal dicatat lalu dimajukan, jadwal lain tetap dijalankan
+	runs, err := service.RunScheduledTransfers(ctx)
+	assert.ErrorContains(t, err, "disk full")
+	if assert.Equal(t, 3, len(runs)) {
+		assert.Equal(t, broken.ID, runs[0].ScheduleId)
+		assert.Equal(t, RunFailed, runs[0].Status)
+		assert.Contains(t, runs[0].Error, "disk full")
+		assert.Equal(t, invalid.ID, runs[1].ScheduleId)
+		assert.Equal(t, RunFailed, runs[1].Status)
+		assert.Contains(t, runs[1].Error, "time zone")
+		assert.Equal(t, healthy.ID, runs[2].ScheduleId)
+		assert.Equal(t, RunSucceeded, runs[2].Status)
+	}
+	assert.Equal(t, int64(10000), walletBalance(t, db, "4"))
+	assert.Equal(t, int64(11000), walletBalance(t, db, "3"))
+
+	var saved ScheduledTransfer
+	assert.Nil(t, db.Take(&saved, "id = ?", broken.ID).Error)
+	assert.Equal(t, ScheduleActive, saved.Status)
+	assert.True(t, saved.NextRunAt.After(time.Now()))
+	var stopped ScheduledTransfer
+	assert.Nil(t, db.Take(&stopped, "id = ?", invalid.ID).Error)
+	assert.Equal(t, ScheduleFailed, stopped.Status)
+
+	runs, err = service.RunScheduledTransfers(ctx)
+	assert.Nil(t, err)
+	assert.Empty(t, runs)
+	assertLedgerConsistent(t, db)
+}
+
+func TestScheduledTransferRetryableError(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	service.MaxAttempts = 2
+	ctx := context.Background()
+
+	// ledger gagal ditulis karena database sedang sibuk, error yang bisa diulang
+	var busy atomic.Bool
+	busy.Store(true)
+	err := db.Callback().Create().Before("gorm:create").Register("test:busy_entries", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*[]WalletEntry); ok && busy.Load() {
+			tx.AddError(sqlite3.Error{Code: sqlite3.ErrBusy})
+		}
+	})
+	assert.Nil(t, err)
+
+	due := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	transfer := ScheduledTransfer{FromWalletId: "2", ToWalletId: "3", Amount: 1000, Schedule: "@every 1h", NextRunAt: due}
+	assert.Nil(t, service.ScheduleTransfer(WithActor(ctx, "2"), &transfer))
+
+	// jadwal tidak dicatat gagal dan tidak dimajukan
+	runs, err := service.RunScheduledTransfers(ctx)
+	assert.True(t, isRetryable(err))
+	assert.Empty(t, runs)
+	var saved ScheduledTransfer
+	assert.Nil(t, db.Preload("Runs").Take(&saved, "id = ?", transfer.ID).Error)
+	assert.True(t, due.Equal(saved.NextRunAt))
+	assert.Empty(t, saved.Runs)
+
+	// pemanggilan berikutnya menjalankan transfer yang sama
+	busy.Store(false)
+	runs, err = service.RunScheduledTransfers(ctx)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(runs)) {
+		assert.Equal(t, RunSucceeded, runs[0].Status)
+		assert.True(t, due.Equal(runs[0].ScheduledFor))
+	}
+	assert.Equal(t, int64(9000), walletBalance(t, db, "2"))
+	assertLedgerConsistent(t, db)
+}
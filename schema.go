@@ -0,0 +1,347 @@
+package belajargorm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Models mengembalikan semua model yang dipetakan ke tabel
+// dipakai oleh CheckSchema dan cmd/schemacheck
+func Models() []interface{} {
+	return []interface{}{
+		&User{},
+		&UserLog{},
+		&Todo{},
+		&Wallet{},
+		&Address{},
+		&WalletEntry{},
+		&IdempotencyKey{},
+		&ExchangeRate{},
+		&WalletHold{},
+		&WalletStatusChange{},
+		&WalletLimit{},
+		&ScheduledTransfer{},
+		&ScheduledTransferRun{},
+		&PendingOperation{},
+		&AddressVersion{},
+	}
+}
+
+// SchemaIssueKind adalah jenis perbedaan antara model dan database
+type SchemaIssueKind string
+
+const (
+	MissingTable      SchemaIssueKind = "missing_table"
+	MissingColumn     SchemaIssueKind = "missing_column"
+	ExtraColumn       SchemaIssueKind = "extra_column"
+	TypeMismatch      SchemaIssueKind = "type_mismatch"
+	NullMismatch      SchemaIssueKind = "null_mismatch"
+	MissingForeignKey SchemaIssueKind = "missing_foreign_key"
+	MissingIndex      SchemaIssueKind = "missing_index"
+)
+
+// SchemaIssue adalah satu perbedaan yang ditemukan oleh CheckSchema
+type SchemaIssue struct {
+	Table  string
+	Column string
+	Kind   SchemaIssueKind
+	Detail string
+}
+
+func (i SchemaIssue) String() string {
+	target := i.Table
+	if i.Column != "" {
+		target += "." + i.Column
+	}
+	return fmt.Sprintf("%s: %s: %s", target, i.Kind, i.Detail)
+}
+
+// SchemaReport adalah hasil CheckSchema
+type SchemaReport struct {
+	Issues []SchemaIssue
+}
+
+// HasDrift bernilai true jika ada perbedaan antara model dan database
+func (r *SchemaReport) HasDrift() bool {
+	return len(r.Issues) > 0
+}
+
+func (r *SchemaReport) String() string {
+	if !r.HasDrift() {
+		return "no schema drift"
+	}
+	lines := make([]string, len(r.Issues))
+	for i, issue := range r.Issues {
+		lines[i] = issue.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *SchemaReport) add(table, column string, kind SchemaIssueKind, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, SchemaIssue{
+		Table:  table,
+		Column: column,
+		Kind:   kind,
+		Detail: fmt.Sprintf(format, args...),
+	})
+}
+
+type foreignKey struct {
+	Column    string `gorm:"column:column_name"`
+	RefTable  string `gorm:"column:ref_table"`
+	RefColumn string `gorm:"column:ref_column"`
+}
+
+func (fk foreignKey) String() string {
+	return fmt.Sprintf("(%s) references %s(%s)", fk.Column, fk.RefTable, fk.RefColumn)
+}
+
+// CheckSchema membandingkan mapping model dengan database yang sedang terhubung
+// dan melaporkan tabel atau kolom yang hilang, kolom tambahan, tipe data dan nullability yang berbeda
+// serta foreign key dan index yang belum ada
+func CheckSchema(db *gorm.DB, models ...interface{}) (*SchemaReport, error) {
+	report := &SchemaReport{}
+	expectedFKs := map[string][]foreignKey{}
+	existingTables := map[string]bool{}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("belajargorm: parse %T: %w", model, err)
+		}
+		s := stmt.Schema
+
+		for _, rel := range s.Relationships.Relations {
+			if rel.Field.IgnoreMigration || rel.JoinTable != nil {
+				continue
+			}
+			constraint := rel.ParseConstraint()
+			if constraint == nil {
+				continue
+			}
+			fk := foreignKey{
+				Column:    joinDBNames(constraint.ForeignKeys),
+				RefTable:  constraint.ReferenceSchema.Table,
+				RefColumn: joinDBNames(constraint.References),
+			}
+			table := constraint.Schema.Table
+			if !containsForeignKey(expectedFKs[table], fk) {
+				expectedFKs[table] = append(expectedFKs[table], fk)
+			}
+		}
+
+		if !db.Migrator().HasTable(s.Table) {
+			report.add(s.Table, "", MissingTable, "table %s for %s does not exist", s.Table, s.Name)
+			continue
+		}
+		existingTables[s.Table] = true
+
+		if err := checkColumns(db, report, model, s); err != nil {
+			return nil, err
+		}
+
+		indexes := s.ParseIndexes()
+		names := make([]string, 0, len(indexes))
+		for name := range indexes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !db.Migrator().HasIndex(model, name) {
+				report.add(s.Table, "", MissingIndex, "index %s does not exist", name)
+			}
+		}
+	}
+
+	tables := make([]string, 0, len(expectedFKs))
+	for table := range expectedFKs {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		if !existingTables[table] && !db.Migrator().HasTable(table) {
+			continue
+		}
+		actual, err := foreignKeys(db, table)
+		if err != nil {
+			return nil, err
+		}
+		for _, fk := range expectedFKs[table] {
+			if !containsForeignKey(actual, fk) {
+				report.add(table, fk.Column, MissingForeignKey, "foreign key %s does not exist", fk)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func checkColumns(db *gorm.DB, report *SchemaReport, model interface{}, s *schema.Schema) error {
+	columnTypes, err := db.Migrator().ColumnTypes(model)
+	if err != nil {
+		return fmt.Errorf("belajargorm: read columns of %s: %w", s.Table, err)
+	}
+	columns := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, c := range columnTypes {
+		columns[c.Name()] = c
+	}
+
+	mapped := map[string]bool{}
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.IgnoreMigration {
+			continue
+		}
+		mapped[field.DBName] = true
+
+		column, ok := columns[field.DBName]
+		if !ok {
+			report.add(s.Table, field.DBName, MissingColumn, "column for %s.%s does not exist", s.Name, field.Name)
+			continue
+		}
+
+		expected := typeFamily(string(field.DataType))
+		actual := typeFamily(column.DatabaseTypeName())
+		if !compatibleTypes(expected, actual) {
+			report.add(s.Table, field.DBName, TypeMismatch, "model expects %s (%s), database has %s", expected, field.DataType, column.DatabaseTypeName())
+		} else if length, ok := column.Length(); ok && expected == "string" && field.Size > 0 && length > 0 && length != int64(field.Size) {
+			report.add(s.Table, field.DBName, TypeMismatch, "model expects size %d, database has %s(%d)", field.Size, column.DatabaseTypeName(), length)
+		}
+
+		nullable, ok := column.Nullable()
+		if pk, _ := column.PrimaryKey(); pk {
+			nullable = false
+		}
+		if !ok {
+			continue
+		}
+		switch {
+		case nullable && (field.NotNull || field.PrimaryKey):
+			report.add(s.Table, field.DBName, NullMismatch, "model expects NOT NULL, database allows NULL")
+		case !nullable && nullableField(field):
+			report.add(s.Table, field.DBName, NullMismatch, "model field %s can be NULL, database column is NOT NULL", field.Name)
+		}
+	}
+
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		if !mapped[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.add(s.Table, name, ExtraColumn, "column is not mapped by %s", s.Name)
+	}
+	return nil
+}
+
+// typeFamily menyederhanakan nama tipe data model atau database menjadi satu keluarga
+// agar bisa dibandingkan antar dialect
+func typeFamily(typ string) string {
+	typ = strings.ToLower(typ)
+	switch schema.DataType(typ) {
+	case schema.Bool:
+		return "bool"
+	case schema.Int, schema.Uint:
+		return "int"
+	case schema.Float:
+		return "float"
+	case schema.String:
+		return "string"
+	case schema.Time:
+		return "time"
+	case schema.Bytes:
+		return "bytes"
+	}
+	switch {
+	case strings.HasPrefix(typ, "bool"), typ == "tinyint(1)":
+		return "bool"
+	case strings.Contains(typ, "int"), strings.Contains(typ, "serial"):
+		return "int"
+	case strings.Contains(typ, "char"), strings.Contains(typ, "text"), strings.Contains(typ, "clob"), typ == "uuid", strings.HasPrefix(typ, "json"):
+		return "string"
+	case strings.Contains(typ, "time"), strings.HasPrefix(typ, "date"):
+		return "time"
+	case strings.HasPrefix(typ, "numeric"), strings.HasPrefix(typ, "decimal"):
+		return "decimal"
+	case strings.Contains(typ, "float"), strings.Contains(typ, "double"), strings.Contains(typ, "real"):
+		return "float"
+	case strings.Contains(typ, "blob"), strings.Contains(typ, "binary"), typ == "bytea":
+		return "bytes"
+	}
+	return typ
+}
+
+func compatibleTypes(expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	pair := expected + "/" + actual
+	switch pair {
+	case "bool/int", // mysql menyimpan bool sebagai tinyint
+		"bool/decimal", // sqlite menyimpan bool sebagai numeric
+		"float/decimal", "decimal/float":
+		return true
+	}
+	return false
+}
+
+// field yang bisa bernilai NULL di Go, seperti pointer, sql.NullString dan gorm.DeletedAt
+func nullableField(field *schema.Field) bool {
+	t := field.FieldType
+	if t.Kind() == reflect.Ptr {
+		return true
+	}
+	return strings.HasPrefix(t.Name(), "Null") || t == reflect.TypeOf(gorm.DeletedAt{})
+}
+
+func joinDBNames(fields []*schema.Field) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.DBName
+	}
+	return strings.Join(names, ",")
+}
+
+func containsForeignKey(fks []foreignKey, fk foreignKey) bool {
+	for _, f := range fks {
+		if f == fk {
+			return true
+		}
+	}
+	return false
+}
+
+// foreignKeys membaca foreign key yang ada di tabel, query berbeda untuk setiap dialect
+func foreignKeys(db *gorm.DB, table string) ([]foreignKey, error) {
+	var query string
+	switch db.Dialector.Name() {
+	case "postgres":
+		query = `SELECT kcu.column_name AS column_name, ccu.table_name AS ref_table, ccu.column_name AS ref_column
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu
+  ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+JOIN information_schema.constraint_column_usage ccu
+  ON ccu.constraint_name = tc.constraint_name AND ccu.constraint_schema = tc.table_schema
+WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = CURRENT_SCHEMA() AND tc.table_name = ?`
+	case "mysql":
+		query = `SELECT column_name AS column_name, referenced_table_name AS ref_table, referenced_column_name AS ref_column
+FROM information_schema.key_column_usage
+WHERE table_schema = DATABASE() AND table_name = ? AND referenced_table_name IS NOT NULL`
+	case "sqlite":
+		query = `SELECT "from" AS column_name, "table" AS ref_table, "to" AS ref_column FROM pragma_foreign_key_list(?)`
+	default:
+		return nil, fmt.Errorf("belajargorm: foreign key introspection is not supported for %s", db.Dialector.Name())
+	}
+
+	var result []foreignKey
+	if err := db.Raw(query, table).Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("belajargorm: read foreign keys of %s: %w", table, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package belajargorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSchema(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	report, err := CheckSchema(db, Models()...)
+	assert.Nil(t, err)
+	assert.False(t, report.HasDrift(), report.String())
+}
+
+func TestCheckSchemaDrift(t *testing.T) {
+	t.Parallel()
+	db := openTestDB(t)
+	if db.Dialector.Name() != "sqlite" {
+		t.Skip("DDL di test ini ditulis untuk sqlite")
+	}
+
+	// kurang lebih seperti database.sql yang lama
+	ddl := []string{
+		`CREATE TABLE users (id varchar(100) NOT NULL PRIMARY KEY, password varchar(100) NOT NULL, name varchar(100) NOT NULL, created_at datetime NOT NULL, updated_at datetime NOT NULL)`,
+		`CREATE TABLE wallets (id varchar(100) NOT NULL PRIMARY KEY, user_id varchar(50), balance text NOT NULL, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE todos (id integer PRIMARY KEY AUTOINCREMENT, user_id varchar(100), title varchar(100), description text, created_at datetime, updated_at datetime, deleted_at datetime NOT NULL)`,
+	}
+	for _, sql := range ddl {
+		assert.Nil(t, db.Exec(sql).Error)
+	}
+
+	report, err := CheckSchema(db, &User{}, &Wallet{}, &Todo{}, &Address{})
+	assert.Nil(t, err)
+	assert.True(t, report.HasDrift())
+
+	found := map[string][]SchemaIssueKind{}
+	for _, issue := range report.Issues {
+		key := issue.Table + "." + issue.Column
+		found[key] = append(found[key], issue.Kind)
+	}
+	assert.Contains(t, found["users.first_name"], MissingColumn)
+	assert.Contains(t, found["users.last_name"], MissingColumn)
+	assert.Contains(t, found["users.name"], ExtraColumn)
+	assert.Contains(t, found["wallets.user_id"], TypeMismatch)
+	assert.Contains(t, found["wallets.balance"], TypeMismatch)
+	assert.Contains(t, found["todos.deleted_at"], NullMismatch)
+	assert.Contains(t, found["todos."], MissingIndex)
+	assert.Contains(t, found["addresses."], MissingTable)
+	assert.Contains(t, found["wallets.user_id"], MissingForeignKey)
+}
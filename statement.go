@@ -0,0 +1,251 @@
+package belajargorm
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPeriod = errors.New("belajargorm: statement period must end after it starts")
+
+// Statement adalah ringkasan mutasi wallet pada periode [From, To)
+// baris mutasinya tidak disimpan di sini, tetapi dikirim satu per satu ke StatementWriter
+type Statement struct {
+	WalletID       string
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance Money
+	ClosingBalance Money
+	TotalDebit     Money
+	TotalCredit    Money
+	Lines          int
+}
+
+// StatementLine adalah satu mutasi di Statement beserta saldo setelah mutasi tersebut
+type StatementLine struct {
+	EntryID   string
+	GroupID   string
+	PostedAt  time.Time
+	Reference string
+	Direction EntryDirection
+	Amount    Money
+	Balance   Money
+}
+
+// StatementWriter menerima Statement secara bertahap
+// Begin dipanggil setelah saldo awal diketahui, Line untuk setiap mutasi, dan End setelah saldo akhir diketahui
+type StatementWriter interface {
+	Begin(statement *Statement) error
+	Line(line StatementLine) error
+	End(statement *Statement) error
+}
+
+// Statement membaca mutasi wallet pada periode [from, to) dari wallet_entries
+// baris dibaca dengan Rows() dan ScanRows lalu dikirim ke out satu per satu bersama saldo berjalannya,
+// sehingga riwayat yang panjang tidak perlu dimuat sekaligus ke memory
+func (s *WalletService) Statement(ctx context.Context, walletID string, from, to time.Time, out StatementWriter) (*Statement, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: %s - %s", ErrInvalidPeriod, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	db := s.db.WithContext(ctx)
+
+	var wallet Wallet
+	err := db.Take(&wallet, "id = ?", walletID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrWalletNotFound, walletID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// created_at di wallet_entries disimpan dalam UTC, lihat insertEntries
+	from, to = from.UTC(), to.UTC()
+	opening, err := ledgerBalance(db.Where("account = ? AND created_at < ?", walletID, from))
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{
+		WalletID:       wallet.ID,
+		Currency:       wallet.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: Money{Amount: opening, Currency: wallet.Currency},
+		TotalDebit:     Money{Currency: wallet.Currency},
+		TotalCredit:    Money{Currency: wallet.Currency},
+	}
+	if err := out.Begin(statement); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Model(&WalletEntry{}).
+		Where("account = ? AND created_at >= ? AND created_at < ?", walletID, from, to).
+		Order("created_at, id").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balance := opening
+	for rows.Next() {
+		var entry WalletEntry
+		if err := db.ScanRows(rows, &entry); err != nil {
+			return nil, err
+		}
+		balance += entry.signed()
+		if entry.Direction == Debit {
+			statement.TotalDebit.Amount += entry.Amount
+		} else {
+			statement.TotalCredit.Amount += entry.Amount
+		}
+		statement.Lines++
+
+		err := out.Line(StatementLine{
+			EntryID:   entry.ID,
+			GroupID:   entry.GroupId,
+			PostedAt:  entry.CreatedAt,
+			Reference: entry.Reference,
+			Direction: entry.Direction,
+			Amount:    Money{Amount: entry.Amount, Currency: entry.Currency},
+			Balance:   Money{Amount: balance, Currency: wallet.Currency},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statement.ClosingBalance = Money{Amount: balance, Currency: wallet.Currency}
+	if err := out.End(statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// StatementCSVWriter menulis Statement sebagai CSV
+// diawali baris saldo awal dan diakhiri baris saldo akhir, jumlah ditulis dalam major unit, contoh 15000.00
+type StatementCSVWriter struct {
+	out *csv.Writer
+}
+
+func NewStatementCSVWriter(w io.Writer) *StatementCSVWriter {
+	return &StatementCSVWriter{out: csv.NewWriter(w)}
+}
+
+func (c *StatementCSVWriter) Begin(statement *Statement) error {
+	if err := c.out.Write([]string{"posted_at", "group_id", "reference", "direction", "amount", "balance", "currency"}); err != nil {
+		return err
+	}
+	return c.balance(statement.From, "opening balance", statement.OpeningBalance)
+}
+
+func (c *StatementCSVWriter) Line(line StatementLine) error {
+	return c.out.Write([]string{
+		line.PostedAt.UTC().Format(time.RFC3339Nano),
+		line.GroupID,
+		line.Reference,
+		string(line.Direction),
+		line.Amount.Decimal(),
+		line.Balance.Decimal(),
+		line.Balance.Currency,
+	})
+}
+
+func (c *StatementCSVWriter) End(statement *Statement) error {
+	if err := c.balance(statement.To, "closing balance", statement.ClosingBalance); err != nil {
+		return err
+	}
+	c.out.Flush()
+	return c.out.Error()
+}
+
+func (c *StatementCSVWriter) balance(at time.Time, reference string, balance Money) error {
+	return c.out.Write([]string{at.UTC().Format(time.RFC3339Nano), "", reference, "", "", balance.Decimal(), balance.Currency})
+}
+
+// StatementJSONWriter menulis Statement sebagai satu objek JSON
+// setiap baris langsung ditulis ke w, jadi tidak perlu menampung semua baris di memory
+//
+//	{"wallet_id":"1","currency":"IDR","from":"...","to":"...","opening_balance":"10000.00",
+//	 "lines":[{...}],"closing_balance":"...","total_debit":"...","total_credit":"..."}
+type StatementJSONWriter struct {
+	w     io.Writer
+	lines int
+}
+
+func NewStatementJSONWriter(w io.Writer) *StatementJSONWriter {
+	return &StatementJSONWriter{w: w}
+}
+
+type statementLineJSON struct {
+	EntryID   string `json:"entry_id"`
+	GroupID   string `json:"group_id"`
+	PostedAt  string `json:"posted_at"`
+	Reference string `json:"reference"`
+	Direction string `json:"direction"`
+	Amount    string `json:"amount"`
+	Balance   string `json:"balance"`
+}
+
+func (j *StatementJSONWriter) Begin(statement *Statement) error {
+	header, err := json.Marshal(map[string]string{
+		"wallet_id":       statement.WalletID,
+		"currency":        statement.Currency,
+		"from":            statement.From.Format(time.RFC3339Nano),
+		"to":              statement.To.Format(time.RFC3339Nano),
+		"opening_balance": statement.OpeningBalance.Decimal(),
+	})
+	if err != nil {
+		return err
+	}
+	// "}" penutup dibuang agar "lines" menjadi field berikutnya di objek yang sama
+	_, err = fmt.Fprintf(j.w, "%s,\"lines\":[", header[:len(header)-1])
+	return err
+}
+
+func (j *StatementJSONWriter) Line(line StatementLine) error {
+	encoded, err := json.Marshal(statementLineJSON{
+		EntryID:   line.EntryID,
+		GroupID:   line.GroupID,
+		PostedAt:  line.PostedAt.UTC().Format(time.RFC3339Nano),
+		Reference: line.Reference,
+		Direction: string(line.Direction),
+		Amount:    line.Amount.Decimal(),
+		Balance:   line.Balance.Decimal(),
+	})
+	if err != nil {
+		return err
+	}
+	if j.lines > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.lines++
+	_, err = j.w.Write(encoded)
+	return err
+}
+
+func (j *StatementJSONWriter) End(statement *Statement) error {
+	footer, err := json.Marshal(map[string]string{
+		"closing_balance": statement.ClosingBalance.Decimal(),
+		"total_debit":     statement.TotalDebit.Decimal(),
+		"total_credit":    statement.TotalCredit.Decimal(),
+	})
+	if err != nil {
+		return err
+	}
+	// "{" pembuka dibuang agar footer melanjutkan objek yang sama
+	_, err = fmt.Fprintf(j.w, "],%s\n", footer[1:])
+	return err
+}
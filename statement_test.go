@@ -0,0 +1,158 @@
+package belajargorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// backdateLastGroup memindahkan waktu group terakhir milik account ke at
+func backdateLastGroup(t *testing.T, db *gorm.DB, account string, at time.Time) {
+	t.Helper()
+	var entry WalletEntry
+	assert.Nil(t, db.Where("account = ?", account).Order("id DESC").Take(&entry).Error)
+	err := db.Model(&WalletEntry{}).Where("group_id = ?", entry.GroupId).Update("created_at", at.UTC()).Error
+	assert.Nil(t, err)
+}
+
+type statementRecorder struct {
+	begin *Statement
+	lines []StatementLine
+}
+
+func (r *statementRecorder) Begin(statement *Statement) error {
+	copied := *statement
+	r.begin = &copied
+	return nil
+}
+
+func (r *statementRecorder) Line(line StatementLine) error {
+	r.lines = append(r.lines, line)
+	return nil
+}
+
+func (r *statementRecorder) End(*Statement) error {
+	return nil
+}
+
+func statementFixture(t *testing.T) (*gorm.DB, *WalletService) {
+	db := newTestDB(t)
+	createWallets(t, db, 2, 0)
+	service := NewWalletService(db)
+	ctx := context.Background()
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	assert.Nil(t, service.Deposit(ctx, "2", 100000, "topup januari"))
+	backdateLastGroup(t, db, "2", time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC))
+	assert.Nil(t, service.Transfer(ctx, "1", "2", 50000))
+	backdateLastGroup(t, db, "2", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, service.Withdraw(ctx, "2", 20000, "tarik tunai"))
+	backdateLastGroup(t, db, "2", time.Date(2024, 2, 29, 23, 59, 59, 0, jakarta))
+	assert.Nil(t, service.Deposit(ctx, "2", 5000, "topup maret"))
+	backdateLastGroup(t, db, "2", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	return db, service
+}
+
+func TestStatement(t *testing.T) {
+	t.Parallel()
+	_, service := statementFixture(t)
+	ctx := context.Background()
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	recorder := &statementRecorder{}
+	statement, err := service.Statement(ctx, "2", from, to, recorder)
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 100000, Currency: "IDR"}, recorder.begin.OpeningBalance)
+	assert.Equal(t, Money{Amount: 130000, Currency: "IDR"}, statement.ClosingBalance)
+	assert.Equal(t, int64(50000), statement.TotalCredit.Amount)
+	assert.Equal(t, int64(20000), statement.TotalDebit.Amount)
+	assert.Equal(t, 2, statement.Lines)
+
+	assert.Equal(t, 2, len(recorder.lines))
+	assert.Equal(t, "transfer", recorder.lines[0].Reference)
+	assert.Equal(t, Credit, recorder.lines[0].Direction)
+	assert.Equal(t, int64(150000), recorder.lines[0].Balance.Amount)
+	assert.Equal(t, "tarik tunai", recorder.lines[1].Reference)
+	assert.Equal(t, int64(130000), recorder.lines[1].Balance.Amount)
+
+	// periode tanpa mutasi
+	statement, err = service.Statement(ctx, "2", to.AddDate(1, 0, 0), to.AddDate(2, 0, 0), &statementRecorder{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(135000), statement.OpeningBalance.Amount)
+	assert.Equal(t, int64(135000), statement.ClosingBalance.Amount)
+	assert.Equal(t, 0, statement.Lines)
+
+	_, err = service.Statement(ctx, "2", to, from, &statementRecorder{})
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+	_, err = service.Statement(ctx, "404", from, to, &statementRecorder{})
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+}
+
+func TestStatementCSV(t *testing.T) {
+	t.Parallel()
+	_, service := statementFixture(t)
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	_, err := service.Statement(context.Background(), "2", from, from.AddDate(0, 1, 0), NewStatementCSVWriter(&buf))
+	assert.Nil(t, err)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(records))
+	assert.Equal(t, []string{"posted_at", "group_id", "reference", "direction", "amount", "balance", "currency"}, records[0])
+	assert.Equal(t, []string{"2024-02-01T00:00:00Z", "", "opening balance", "", "", "1000.00", "IDR"}, records[1])
+	assert.Equal(t, "2024-02-01T00:00:00Z", records[2][0])
+	assert.Equal(t, []string{"transfer", "credit", "500.00", "1500.00", "IDR"}, records[2][2:])
+	assert.Equal(t, []string{"2024-02-29T16:59:59Z"}, records[3][:1])
+	assert.Equal(t, []string{"tarik tunai", "debit", "200.00", "1300.00", "IDR"}, records[3][2:])
+	assert.Equal(t, []string{"2024-03-01T00:00:00Z", "", "closing balance", "", "", "1300.00", "IDR"}, records[4])
+}
+
+func TestStatementJSON(t *testing.T) {
+	t.Parallel()
+	_, service := statementFixture(t)
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	_, err := service.Statement(context.Background(), "2", from, from.AddDate(0, 1, 0), NewStatementJSONWriter(&buf))
+	assert.Nil(t, err)
+
+	var result struct {
+		WalletID       string `json:"wallet_id"`
+		Currency       string `json:"currency"`
+		OpeningBalance string `json:"opening_balance"`
+		ClosingBalance string `json:"closing_balance"`
+		TotalDebit     string `json:"total_debit"`
+		TotalCredit    string `json:"total_credit"`
+		Lines          []struct {
+			Reference string `json:"reference"`
+			Amount    string `json:"amount"`
+			Balance   string `json:"balance"`
+		} `json:"lines"`
+	}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, "2", result.WalletID)
+	assert.Equal(t, "IDR", result.Currency)
+	assert.Equal(t, "1000.00", result.OpeningBalance)
+	assert.Equal(t, "1300.00", result.ClosingBalance)
+	assert.Equal(t, "200.00", result.TotalDebit)
+	assert.Equal(t, "500.00", result.TotalCredit)
+	assert.Equal(t, 2, len(result.Lines))
+	assert.Equal(t, "1500.00", result.Lines[0].Balance)
+
+	// periode tanpa mutasi tetap menghasilkan JSON yang valid
+	buf.Reset()
+	_, err = service.Statement(context.Background(), "2", from.AddDate(2, 0, 0), from.AddDate(3, 0, 0), NewStatementJSONWriter(&buf))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, 0, len(result.Lines))
+	assert.Equal(t, "1350.00", result.ClosingBalance)
+}
@@ -10,9 +10,9 @@ type Todo struct {
 	// UpdatedAt time.Time
 	// DeletedAt DeletedAt `gorm:"index"`
 	// dan cocok digunakan jika field struct sesuai dengan model convention GORM
-	UserId      string `gorm:"column:user_id"`
-	Title       string `gorm:"column:title"`
-	Description string `gorm:"column:description"`
+	UserId      string `gorm:"column:user_id;size:100"`
+	Title       string `gorm:"column:title;size:100"`
+	Description string `gorm:"column:description;type:text"`
 }
 
 func (t *Todo) TableName() string {
@@ -1,16 +1,30 @@
 package belajargorm
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUserNotFound = errors.New("belajargorm: user not found")
 
 type User struct {
-	ID          string    `gorm:"primaryKey;column:id;<-:create"`
-	Password    string    `gorm:"column:password"`
+	ID          string    `gorm:"primaryKey;column:id;size:100;<-:create"`
+	Password    string    `gorm:"column:password;size:100"`
 	Name        Name      `gorm:"embedded"`
 	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime;<-:create"`      // tidak perlu ditambahkan autoCreateTime
 	UpdatedAt   time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"` // tidak perlu ditambahkan autoCreateTime dan autoUpdateTime
 	Information string    `gorm:"-"`
-	Wallet      Wallet    `gorm:"foreignKey:user_id;references:id"`
-	Addresses   []Address `gorm:"foreignKey:user_id;references:id"`
+	// passwordHash adalah hash yang dibaca dari database atau dibuat oleh hashPassword
+	// Password yang sama dengan passwordHash tidak di-hash ulang saat disimpan
+	passwordHash string    `gorm:"-"`
+	Wallet       Wallet    `gorm:"foreignKey:user_id;references:id"`
+	Wallets      []Wallet  `gorm:"foreignKey:user_id;references:id"`
+	Addresses    []Address `gorm:"foreignKey:user_id;references:id"`
+	// Wallet adalah relasi has one dari sebelum user bisa punya banyak wallet
+	// jika user punya lebih dari satu wallet, Preload("Wallet", "is_default = ?", true) agar yang dimuat wallet default
+	// Wallets adalah relasi has many berisi semua wallet user, lihat juga WalletService.DefaultWallet
 	// foreignKey merujuk kolom yang dijadikan sebagai foreign key yang ada di tabel relasi yaitu wallet dan addresses
 	// references merujuk pada kolom id pada tabel saat ini yaitu tabel user
 }
@@ -27,6 +41,80 @@ func (u *User) TableName() string {
 	return "users"
 }
 
+// hook BeforeSave dipanggil sebelum create maupun update
+// password selalu disimpan dalam bentuk hash, termasuk saat
+// Update("password", ...) atau Updates(map[string]interface{}{"password": ...})
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	switch dest := tx.Statement.Dest.(type) {
+	case map[string]interface{}:
+		return hashPasswordInMap(dest)
+	case User:
+		// Model(&user).Updates(User{Password: ...})
+		hash, err := HashPassword(dest.Password)
+		if err != nil {
+			return err
+		}
+		if hash != dest.Password {
+			tx.Statement.SetColumn("Password", hash)
+		}
+	case *User:
+		if dest != u {
+			if err := dest.hashPassword(); err != nil {
+				return err
+			}
+		}
+	}
+	return u.hashPassword()
+}
+
+// hook BeforeCreate mengisi ID jika masih kosong, lihat DefaultIDGenerator
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID != "" {
+		return nil
+	}
+	id, err := NewID()
+	if err != nil {
+		return err
+	}
+	u.ID = id
+	return nil
+}
+
+// hook AfterFind mengingat hash password dari database, lihat hashPassword
+func (u *User) AfterFind(tx *gorm.DB) error {
+	u.passwordHash = u.Password
+	return nil
+}
+
+// hashPassword membuat hash dari Password kecuali Password masih berisi hash dari database
+// apakah Password sudah berupa hash tidak ditentukan dari isinya, lihat HashPassword
+func (u *User) hashPassword() error {
+	if u.Password != "" && u.Password == u.passwordHash {
+		return nil
+	}
+	hash, err := HashPassword(u.Password)
+	if err != nil {
+		return err
+	}
+	u.Password, u.passwordHash = hash, hash
+	return nil
+}
+
+// VerifyPassword mencocokkan password dengan hash yang tersimpan
+// jika cocok dan hash dibuat dengan parameter lama, Password diganti dengan hash baru
+// sehingga cukup disimpan ulang, atau gunakan Authenticate
+func (u *User) VerifyPassword(password string) bool {
+	if !DefaultPasswordHasher.Verify(u.Password, password) {
+		return false
+	}
+	if DefaultPasswordHasher.NeedsRehash(u.Password) {
+		if hash, err := DefaultPasswordHasher.Hash(password); err == nil {
+			u.Password, u.passwordHash = hash, hash
+		}
+	}
+	return true
+}
+
 // field permission
 // <- = write permission, create and update
 // <-:create = create only
@@ -39,19 +127,23 @@ func (u *User) TableName() string {
 // dilakukan dengan menambahkan gorm:"embedded"
 // contoh
 type Name struct {
-	FirstName  string `gorm:"column:first_name"`
-	MiddleName string `gorm:"column:middle_name"`
-	LastName   string `gorm:"column:last_name"`
+	FirstName  string `gorm:"column:first_name;size:100"`
+	MiddleName string `gorm:"column:middle_name;size:100"`
+	LastName   string `gorm:"column:last_name;size:100"`
 }
 
 // lalu sisipkan ke field
 
 type UserLog struct {
-	ID        int       `gorm:"primaryKey;column:id;autoIncrement"`
-	UserId    string    `gorm:"column:user_id"`
-	Action    string    `gorm:"column:action"`
-	CreatedAt time.Time `gorm:"column:created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at"`
+	ID        int         `gorm:"primaryKey;column:id;autoIncrement"`
+	UserId    string      `gorm:"column:user_id;size:100"`
+	ActorId   string      `gorm:"column:actor_id;size:100"` // user yang melakukan aksi, diambil dari context
+	Action    AuditAction `gorm:"column:action;size:100"`
+	Entity    string      `gorm:"column:entity;size:100;index:idx_user_logs_entity,priority:1"` // nama tabel
+	EntityId  string      `gorm:"column:entity_id;size:100;index:idx_user_logs_entity,priority:2"`
+	Changes   string      `gorm:"column:changes;type:text"` // JSON {"kolom": {"old": ..., "new": ...}}
+	CreatedAt time.Time   `gorm:"column:created_at"`
+	UpdatedAt time.Time   `gorm:"column:updated_at"`
 }
 
 func (l *UserLog) TableName() string {
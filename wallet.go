@@ -1,18 +1,130 @@
 package belajargorm
 
-import "time"
+import (
+	"fmt"
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// Balance adalah proyeksi dari wallet_entries dan hanya ditulis saat create (saldo awal)
+// perubahan saldo berikutnya harus melalui WalletService, lihat juga RebuildBalances
+// Balance dalam minor unit Currency, dan Currency tidak bisa diubah setelah wallet dibuat
+// Status hanya bisa diubah lewat WalletService.ChangeStatus
+// IsDefault menandai wallet default user, hanya satu per user (idx_wallets_default), ubah lewat WalletService.SetDefaultWallet
+// idx_wallets_default dibuat oleh migration 8, tidak ditulis di tag karena AutoMigrate di mysql membuatnya tanpa where
 type Wallet struct {
 	// field UserId dijadikan sebagai foreign key yang merujuk pada kolom id di tabel users
-	ID        string    `gorm:"primary_key;column:id"`
-	UserId    string    `gorm:"column:user_id"`
-	Balance   int64     `gorm:"column:balance"`
-	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
-	User      *User     `gorm:"foreignKey:user_id;references:id"`
+	ID        string       `gorm:"primaryKey;column:id;size:100"`
+	UserId    string       `gorm:"column:user_id;size:100;index:idx_wallets_user"`
+	Balance   int64        `gorm:"column:balance;<-:create"`
+	Currency  string       `gorm:"column:currency;size:3;not null;<-:create"`
+	Status    WalletStatus `gorm:"column:status;size:20;not null;<-:create"`
+	IsDefault bool         `gorm:"column:is_default;not null;default:false;<-:create"`
+	CreatedAt time.Time    `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time    `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	User      *User        `gorm:"foreignKey:user_id;references:id"`
 	// gunakan pointer untuk menghindari cylic
 }
 
 func (w *Wallet) TableName() string {
 	return "wallets"
 }
+
+// hook BeforeCreate mengisi ID, Currency dan Status jika masih kosong, lihat DefaultIDGenerator dan DefaultCurrency
+// wallet pertama milik user otomatis menjadi wallet default
+func (w *Wallet) BeforeCreate(tx *gorm.DB) error {
+	if err := w.claimDefault(tx); err != nil {
+		return err
+	}
+	if w.Currency == "" {
+		w.Currency = DefaultCurrency
+	}
+	if w.Status == "" {
+		w.Status = WalletActive
+	}
+	if w.Status != WalletActive && w.Status != WalletFrozen {
+		return fmt.Errorf("%w: cannot create a %s wallet", ErrInvalidStatusTransition, w.Status)
+	}
+	if _, err := CurrencyExponent(w.Currency); err != nil {
+		return err
+	}
+	if w.ID != "" {
+		return w.markExisting(tx)
+	}
+	id, err := NewID()
+	if err != nil {
+		return err
+	}
+	w.ID = id
+	return nil
+}
+
+// claimDefault menjadikan w wallet default jika user belum punya wallet default
+// saat create banyak wallet sekaligus (misalnya User.Wallets), hook dipanggil untuk setiap wallet
+// dengan Statement yang sama, jadi hanya wallet pertama di batch yang menjadi default
+func (w *Wallet) claimDefault(tx *gorm.DB) error {
+	if w.UserId == "" {
+		return nil
+	}
+	key := "belajargorm:default_wallet:" + w.UserId
+	if _, claimed := tx.Statement.Settings.Load(key); claimed {
+		return nil
+	}
+	if !w.IsDefault {
+		var count int64
+		err := tx.Model(&Wallet{}).Where("user_id = ? AND is_default = ?", w.UserId, true).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		w.IsDefault = count == 0
+	}
+	if w.IsDefault {
+		tx.Statement.Settings.Store(key, w.ID)
+	}
+	return nil
+}
+
+// BalanceMoney mengembalikan Balance beserta mata uangnya
+func (w *Wallet) BalanceMoney() Money {
+	return Money{Amount: w.Balance, Currency: w.Currency}
+}
+
+// markExisting menandai wallet yang sudah ada sebelum create, misalnya saat Save user melakukan upsert wallet
+// upsert tidak mengubah kolom balance (<-:create), jadi AfterCreate tidak boleh mencatat saldo awal
+func (w *Wallet) markExisting(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Model(&Wallet{}).Where("id = ?", w.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		tx.Statement.Settings.Store("belajargorm:existing_wallet:"+w.ID, true)
+	}
+	return nil
+}
+
+// hook AfterCreate mencatat saldo awal ke ledger agar saldo bisa dihitung ulang dari wallet_entries
+// hanya untuk wallet yang benar-benar di-insert, bukan wallet lama yang terkena upsert (lihat markExisting)
+// dan dilewati jika wallet sudah punya entry
+func (w *Wallet) AfterCreate(tx *gorm.DB) error {
+	if w.Balance == 0 {
+		return nil
+	}
+	if _, existing := tx.Statement.Settings.Load("belajargorm:existing_wallet:" + w.ID); existing {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&WalletEntry{}).Where("account = ?", w.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	entries := transferEntries(AccountExternal, w.ID, w.BalanceMoney())
+	if w.Balance < 0 {
+		entries = transferEntries(w.ID, AccountExternal, w.BalanceMoney().Neg())
+	}
+	_, err := insertEntries(tx, "opening balance", entries)
+	return err
+}
@@ -0,0 +1,79 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultWallet mengembalikan wallet default milik user
+// ErrWalletNotFound jika user belum punya wallet
+func (s *WalletService) DefaultWallet(ctx context.Context, userID string) (*Wallet, error) {
+	var wallet Wallet
+	err := s.db.WithContext(ctx).Take(&wallet, "user_id = ? AND is_default = ?", userID, true).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: user %s has no default wallet", ErrWalletNotFound, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// SetDefaultWallet menjadikan walletID wallet default milik userID
+// semua wallet user dikunci lebih dulu agar dua pergantian yang bersamaan tidak melanggar idx_wallets_default
+// wallet yang closed tidak bisa dijadikan default
+func (s *WalletService) SetDefaultWallet(ctx context.Context, userID, walletID string) error {
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		var wallets []Wallet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).Order("id").Find(&wallets).Error
+		if err != nil {
+			return err
+		}
+
+		var target *Wallet
+		for i := range wallets {
+			if wallets[i].ID == walletID {
+				target = &wallets[i]
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("%w: %s of user %s", ErrWalletNotFound, walletID, userID)
+		}
+		if target.Status == WalletClosed {
+			return &WalletStatusError{WalletID: target.ID, Status: target.Status, Operation: "set default"}
+		}
+		if target.IsDefault {
+			return nil
+		}
+
+		// default lama dilepas lebih dulu, unique index diperiksa per statement
+		now := tx.NowFunc()
+		err = tx.Table("wallets").Where("user_id = ? AND is_default = ?", userID, true).
+			Updates(map[string]interface{}{"is_default": false, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Table("wallets").Where("id = ?", target.ID).
+			Updates(map[string]interface{}{"is_default": true, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+
+		// is_default ditulis lewat Table sehingga dicatat sendiri ke user_logs
+		for _, wallet := range wallets {
+			if wallet.IsDefault == (wallet.ID == target.ID) {
+				continue
+			}
+			changes := map[string]AuditChange{"is_default": {Old: wallet.IsDefault, New: !wallet.IsDefault}}
+			if err := writeWalletLog(tx, wallet.ID, userID, changes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,121 @@
+package belajargorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultWallet(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	// wallet fixture menjadi default oleh migration maupun hook BeforeCreate
+	wallet, err := service.DefaultWallet(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", wallet.ID)
+
+	savings := Wallet{ID: "savings", UserId: "1", Currency: "USD"}
+	assert.Nil(t, db.Create(&savings).Error)
+	assert.False(t, savings.IsDefault)
+
+	var user User
+	assert.Nil(t, db.Preload("Wallets").Take(&user, "id = ?", "1").Error)
+	assert.Equal(t, 2, len(user.Wallets))
+
+	assert.Nil(t, service.SetDefaultWallet(ctx, "1", "savings"))
+	wallet, err = service.DefaultWallet(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "savings", wallet.ID)
+
+	assert.Nil(t, db.Preload("Wallet", "is_default = ?", true).Take(&user, "id = ?", "1").Error)
+	assert.Equal(t, "savings", user.Wallet.ID)
+
+	var count int64
+	assert.Nil(t, db.Model(&Wallet{}).Where("user_id = ? AND is_default = ?", "1", true).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// pergantian default dicatat di user_logs untuk kedua wallet
+	for id, old := range map[string]bool{"1": true, "savings": false} {
+		var logs []UserLog
+		err := db.Where("entity = ? AND entity_id = ? AND action = ?", "wallets", id, AuditUpdate).Find(&logs).Error
+		assert.Nil(t, err)
+		if assert.Equal(t, 1, len(logs), id) {
+			assert.Equal(t, AuditChange{Old: old, New: !old}, auditChanges(t, logs[0])["is_default"])
+		}
+	}
+
+	// sudah default, tidak ada perubahan
+	assert.Nil(t, service.SetDefaultWallet(ctx, "1", "savings"))
+
+	// idx_wallets_default menolak default kedua
+	assert.NotNil(t, db.Create(&Wallet{ID: "spending", UserId: "1", IsDefault: true}).Error)
+}
+
+func TestDefaultWalletErrors(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	_, err := service.DefaultWallet(ctx, "2")
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+
+	// wallet milik user lain
+	assert.ErrorIs(t, service.SetDefaultWallet(ctx, "2", "1"), ErrWalletNotFound)
+
+	assert.Nil(t, db.Create(&Wallet{ID: "old", UserId: "1"}).Error)
+	assert.Nil(t, service.Close(ctx, "old", "tidak dipakai"))
+	assert.ErrorIs(t, service.SetDefaultWallet(ctx, "1", "old"), ErrWalletClosed)
+}
+
+func TestCreateUserWithWallets(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+
+	user := User{
+		ID:       "30",
+		Password: "rahasia",
+		Name:     Name{FirstName: "User 30"},
+		Wallets: []Wallet{
+			{ID: "30-spending", Balance: 1000},
+			{ID: "30-savings", Balance: 5000},
+		},
+	}
+	assert.Nil(t, db.Create(&user).Error)
+	assert.True(t, user.Wallets[0].IsDefault)
+	assert.False(t, user.Wallets[1].IsDefault)
+
+	wallet, err := NewWalletService(db).DefaultWallet(context.Background(), "30")
+	assert.Nil(t, err)
+	assert.Equal(t, "30-spending", wallet.ID)
+	assertLedgerConsistent(t, db)
+}
+
+func TestDefaultWalletMigration(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	for {
+		done, err := migrator.Rollback(ctx, 1)
+		assert.Nil(t, err)
+		if len(done) == 0 || done[0].Version == 8 {
+			break
+		}
+	}
+	assert.False(t, db.Migrator().HasColumn(&Wallet{}, "is_default"))
+
+	_, err := migrator.Apply(ctx)
+	assert.Nil(t, err)
+
+	var wallet Wallet
+	assert.Nil(t, db.Take(&wallet, "id = ?", "1").Error)
+	assert.True(t, wallet.IsDefault)
+	assert.True(t, db.Migrator().HasIndex(&Wallet{}, "idx_wallets_default"))
+	assert.False(t, db.Migrator().HasIndex(&Wallet{}, "idx_wallets_user_id"))
+}
@@ -0,0 +1,222 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HoldStatus adalah status WalletHold
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"
+	HoldCaptured HoldStatus = "captured"
+	HoldVoided   HoldStatus = "voided"
+	HoldExpired  HoldStatus = "expired"
+)
+
+var (
+	ErrHoldNotFound        = errors.New("belajargorm: hold not found")
+	ErrHoldNotActive       = errors.New("belajargorm: hold is not active")
+	ErrCaptureExceedsHold  = errors.New("belajargorm: capture amount exceeds hold")
+	ErrInvalidHoldDuration = errors.New("belajargorm: hold ttl must be positive")
+)
+
+// WalletHold menahan sebagian saldo wallet tanpa mendebitnya, misalnya saat checkout
+// hold yang masih aktif mengurangi saldo yang tersedia (lihat AvailableBalance)
+// sampai di-capture, di-void atau melewati ExpiresAt
+type WalletHold struct {
+	ID             string     `gorm:"primaryKey;column:id;size:100"`
+	WalletId       string     `gorm:"column:wallet_id;size:100;not null;index:idx_wallet_holds_wallet_status,priority:1"`
+	Amount         int64      `gorm:"column:amount;not null"`
+	CapturedAmount int64      `gorm:"column:captured_amount;not null"`
+	Currency       string     `gorm:"column:currency;size:3;not null"`
+	Status         HoldStatus `gorm:"column:status;size:20;not null;index:idx_wallet_holds_wallet_status,priority:2"`
+	ExpiresAt      time.Time  `gorm:"column:expires_at;not null;index"`
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	Wallet         *Wallet    `gorm:"foreignKey:wallet_id;references:id"`
+}
+
+func (h *WalletHold) TableName() string {
+	return "wallet_holds"
+}
+
+// hook BeforeCreate mengisi ID jika masih kosong, lihat DefaultIDGenerator
+func (h *WalletHold) BeforeCreate(tx *gorm.DB) error {
+	if h.ID != "" {
+		return nil
+	}
+	id, err := NewID()
+	if err != nil {
+		return err
+	}
+	h.ID = id
+	return nil
+}
+
+// heldAmount menjumlahkan hold aktif yang belum kadaluarsa milik wallet
+func heldAmount(tx *gorm.DB, walletID string, now time.Time) (int64, error) {
+	var held int64
+	err := tx.Model(&WalletHold{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("wallet_id = ? AND status = ? AND expires_at > ?", walletID, HoldActive, now.UTC()).
+		Scan(&held).Error
+	return held, err
+}
+
+// availableBalance adalah saldo wallet dikurangi hold yang masih aktif
+// wallet sebaiknya sudah dikunci dengan lockWallets agar hasilnya tidak berubah
+func availableBalance(tx *gorm.DB, wallet *Wallet) (int64, error) {
+	held, err := heldAmount(tx, wallet.ID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return wallet.Balance - held, nil
+}
+
+// AvailableBalance mengembalikan saldo wallet yang bisa dipakai, yaitu saldo dikurangi hold aktif
+func (s *WalletService) AvailableBalance(ctx context.Context, walletID string) (Money, error) {
+	var wallet Wallet
+	err := s.db.WithContext(ctx).Take(&wallet, "id = ?", walletID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Money{}, fmt.Errorf("%w: %s", ErrWalletNotFound, walletID)
+	}
+	if err != nil {
+		return Money{}, err
+	}
+	available, err := availableBalance(s.db.WithContext(ctx), &wallet)
+	return Money{Amount: available, Currency: wallet.Currency}, err
+}
+
+// Authorize menahan amount dari saldo wallet yang tersedia selama ttl
+// hold diperiksa terhadap ApprovalThresholds seperti Withdraw, karena Capture mendebit saldo ke luar sistem
+// dan tidak bisa melebihi hold, hold di atas batas harus diajukan lewat ProposeOperation
+func (s *WalletService) Authorize(ctx context.Context, walletID string, amount int64, ttl time.Duration) (*WalletHold, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidAmount, amount)
+	}
+	if ttl <= 0 {
+		return nil, ErrInvalidHoldDuration
+	}
+
+	var hold *WalletHold
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		wallets, err := lockWallets(tx, walletID)
+		if err != nil {
+			return err
+		}
+		wallet := wallets[walletID]
+		if err := checkDebit(wallet); err != nil {
+			return err
+		}
+		if err := s.checkApproval(OperationWithdraw, wallet, amount); err != nil {
+			return err
+		}
+		if err := checkAvailable(tx, wallet, amount); err != nil {
+			return err
+		}
+
+		hold = &WalletHold{
+			WalletId:  wallet.ID,
+			Amount:    amount,
+			Currency:  wallet.Currency,
+			Status:    HoldActive,
+			ExpiresAt: time.Now().Add(ttl).UTC(),
+		}
+		return tx.Create(hold).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// lockHold mengunci wallet pemilik hold lalu hold itu sendiri, dengan urutan yang sama seperti Transfer
+// sehingga tidak terjadi deadlock dengan operasi lain pada wallet yang sama
+func lockHold(tx *gorm.DB, holdID string) (*WalletHold, *Wallet, error) {
+	var walletID string
+	err := tx.Model(&WalletHold{}).Select("wallet_id").Where("id = ?", holdID).Take(&walletID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrHoldNotFound, holdID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	wallets, err := lockWallets(tx, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var hold WalletHold
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&hold, "id = ?", holdID).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	if hold.Status != HoldActive {
+		return nil, nil, fmt.Errorf("%w: %s is %s", ErrHoldNotActive, hold.ID, hold.Status)
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		return nil, nil, fmt.Errorf("%w: %s is %s", ErrHoldNotActive, hold.ID, HoldExpired)
+	}
+	return &hold, wallets[walletID], nil
+}
+
+// Capture mendebit amount dari hold ke luar sistem (AccountExternal)
+// amount boleh lebih kecil dari hold, sisa hold dilepas dan hold tidak bisa di-capture lagi
+func (s *WalletService) Capture(ctx context.Context, holdID string, amount int64) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidAmount, amount)
+	}
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		hold, wallet, err := lockHold(tx, holdID)
+		if err != nil {
+			return err
+		}
+		if err := checkDebit(wallet); err != nil {
+			return err
+		}
+		if amount > hold.Amount {
+			return fmt.Errorf("%w: hold %s is %s, capture %s", ErrCaptureExceedsHold, hold.ID,
+				Money{Amount: hold.Amount, Currency: hold.Currency}, Money{Amount: amount, Currency: hold.Currency})
+		}
+		if err := s.checkLimits(tx, wallet, amount); err != nil {
+			return err
+		}
+
+		debit := Money{Amount: amount, Currency: wallet.Currency}
+		if _, err := postEntries(tx, "capture "+hold.ID, transferEntries(wallet.ID, AccountExternal, debit)); err != nil {
+			return err
+		}
+		return tx.Model(hold).Updates(map[string]interface{}{
+			"status":          HoldCaptured,
+			"captured_amount": amount,
+		}).Error
+	})
+}
+
+// Void melepas hold tanpa mendebit saldo
+func (s *WalletService) Void(ctx context.Context, holdID string) error {
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		hold, _, err := lockHold(tx, holdID)
+		if err != nil {
+			return err
+		}
+		return tx.Model(hold).Update("status", HoldVoided).Error
+	})
+}
+
+// ReleaseExpiredHolds mengubah status hold aktif yang sudah melewati ExpiresAt menjadi expired
+// hold yang kadaluarsa sudah tidak dihitung oleh AvailableBalance, fungsi ini hanya merapikan statusnya
+// dan bisa dijalankan berkala, misalnya dari cron
+func (s *WalletService) ReleaseExpiredHolds(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Model(&WalletHold{}).
+		Where("status = ? AND expires_at <= ?", HoldActive, time.Now().UTC()).
+		Update("status", HoldExpired)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,117 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func availableAmount(t *testing.T, service *WalletService, walletID string) int64 {
+	t.Helper()
+	available, err := service.AvailableBalance(context.Background(), walletID)
+	assert.Nil(t, err)
+	return available.Amount
+}
+
+func TestHoldAuthorizeCapture(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 0)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	hold, err := service.Authorize(ctx, "1", 600000, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, HoldActive, hold.Status)
+	assert.Equal(t, "IDR", hold.Currency)
+	assert.Equal(t, int64(400000), availableAmount(t, service, "1"))
+	assert.Equal(t, int64(1000000), walletBalance(t, db, "1"))
+
+	// saldo yang ditahan tidak bisa dipakai untuk transfer atau hold lain
+	err = service.Transfer(ctx, "1", "2", 500000)
+	var insufficient *InsufficientBalanceError
+	assert.True(t, errors.As(err, &insufficient))
+	assert.Equal(t, int64(400000), insufficient.Balance)
+	_, err = service.Authorize(ctx, "1", 500000, time.Hour)
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+	assert.ErrorIs(t, service.Capture(ctx, hold.ID, 700000), ErrCaptureExceedsHold)
+	assert.Nil(t, service.Capture(ctx, hold.ID, 250000))
+	assert.Equal(t, int64(750000), walletBalance(t, db, "1"))
+	assert.Equal(t, int64(750000), availableAmount(t, service, "1"))
+
+	assert.ErrorIs(t, service.Capture(ctx, hold.ID, 100), ErrHoldNotActive)
+	assert.ErrorIs(t, service.Void(ctx, hold.ID), ErrHoldNotActive)
+	assert.ErrorIs(t, service.Void(ctx, "404"), ErrHoldNotFound)
+
+	var stored WalletHold
+	assert.Nil(t, db.Take(&stored, "id = ?", hold.ID).Error)
+	assert.Equal(t, HoldCaptured, stored.Status)
+	assert.Equal(t, int64(250000), stored.CapturedAmount)
+	assertLedgerConsistent(t, db)
+}
+
+func TestHoldVoidAndExpire(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	voided, err := service.Authorize(ctx, "1", 100000, time.Hour)
+	assert.Nil(t, err)
+	expired, err := service.Authorize(ctx, "1", 200000, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(700000), availableAmount(t, service, "1"))
+
+	assert.Nil(t, service.Void(ctx, voided.ID))
+	assert.Equal(t, int64(800000), availableAmount(t, service, "1"))
+
+	// hold yang kadaluarsa langsung tidak dihitung, meskipun statusnya masih active
+	err = db.Model(&WalletHold{}).Where("id = ?", expired.ID).Update("expires_at", time.Now().UTC().Add(-time.Second)).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000000), availableAmount(t, service, "1"))
+	assert.ErrorIs(t, service.Capture(ctx, expired.ID, 100), ErrHoldNotActive)
+
+	released, err := service.ReleaseExpiredHolds(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), released)
+
+	var statuses []HoldStatus
+	assert.Nil(t, db.Model(&WalletHold{}).Order("id").Pluck("status", &statuses).Error)
+	assert.Equal(t, []HoldStatus{HoldVoided, HoldExpired}, statuses)
+
+	_, err = service.Authorize(ctx, "1", 100, 0)
+	assert.ErrorIs(t, err, ErrInvalidHoldDuration)
+	_, err = service.Authorize(ctx, "404", 100, time.Hour)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+}
+
+func TestHoldConcurrentAuthorize(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var authorized int32
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Authorize(ctx, "1", 300000, time.Hour)
+			if err == nil {
+				atomic.AddInt32(&authorized, 1)
+			} else {
+				assert.ErrorIs(t, err, ErrInsufficientBalance)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), authorized)
+	assert.Equal(t, int64(100000), availableAmount(t, service, "1"))
+}
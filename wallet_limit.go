@@ -0,0 +1,208 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LimitRule adalah aturan batas debit wallet
+type LimitRule string
+
+const (
+	// LimitDailyDebit membatasi total debit sejak awal hari ini
+	LimitDailyDebit LimitRule = "daily_debit"
+	// LimitMonthlyDebit membatasi total debit sejak awal bulan ini
+	LimitMonthlyDebit LimitRule = "monthly_debit"
+	// LimitHourlyTransactions membatasi jumlah debit dalam satu jam terakhir
+	LimitHourlyTransactions LimitRule = "hourly_transactions"
+)
+
+var (
+	ErrLimitExceeded = errors.New("belajargorm: wallet limit exceeded")
+	ErrInvalidLimit  = errors.New("belajargorm: limit must not be negative")
+)
+
+// LimitExceededError dikembalikan jika debit melanggar salah satu LimitRule
+// Limit, Used, Amount dan Remaining dalam minor unit Currency, kecuali LimitHourlyTransactions yang berupa jumlah transaksi
+// errors.Is(err, ErrLimitExceeded) bernilai true
+type LimitExceededError struct {
+	WalletID  string
+	Rule      LimitRule
+	Currency  string
+	Limit     int64
+	Used      int64
+	Amount    int64
+	Remaining int64
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Rule == LimitHourlyTransactions {
+		return fmt.Sprintf("belajargorm: %s limit %d exceeded for wallet %s: used %d, remaining %d",
+			e.Rule, e.Limit, e.WalletID, e.Used, e.Remaining)
+	}
+	money := func(amount int64) Money { return Money{Amount: amount, Currency: e.Currency} }
+	return fmt.Sprintf("belajargorm: %s limit %s exceeded for wallet %s: used %s, amount %s, remaining %s",
+		e.Rule, money(e.Limit), e.WalletID, money(e.Used), money(e.Amount), money(e.Remaining))
+}
+
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Limits adalah batas debit yang berlaku untuk satu wallet, nilai 0 berarti tanpa batas
+// DailyDebit dan MonthlyDebit dalam minor unit mata uang wallet
+type Limits struct {
+	DailyDebit         int64
+	MonthlyDebit       int64
+	HourlyTransactions int64
+}
+
+func (l Limits) empty() bool {
+	return l.DailyDebit == 0 && l.MonthlyDebit == 0 && l.HourlyTransactions == 0
+}
+
+// WalletLimit menyimpan batas khusus untuk satu wallet
+// kolom NULL berarti memakai WalletService.DefaultLimits, 0 berarti tanpa batas
+type WalletLimit struct {
+	WalletId           string    `gorm:"primaryKey;column:wallet_id;size:100"`
+	DailyDebit         *int64    `gorm:"column:daily_debit"`
+	MonthlyDebit       *int64    `gorm:"column:monthly_debit"`
+	HourlyTransactions *int64    `gorm:"column:hourly_transactions"`
+	CreatedAt          time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt          time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	Wallet             *Wallet   `gorm:"foreignKey:wallet_id;references:id"`
+}
+
+func (l *WalletLimit) TableName() string {
+	return "wallet_limits"
+}
+
+// apply menimpa defaults dengan kolom yang tidak NULL
+func (l *WalletLimit) apply(defaults Limits) Limits {
+	if l.DailyDebit != nil {
+		defaults.DailyDebit = *l.DailyDebit
+	}
+	if l.MonthlyDebit != nil {
+		defaults.MonthlyDebit = *l.MonthlyDebit
+	}
+	if l.HourlyTransactions != nil {
+		defaults.HourlyTransactions = *l.HourlyTransactions
+	}
+	return defaults
+}
+
+// SetWalletLimit menyimpan batas khusus wallet, menggantikan batas khusus sebelumnya
+func (s *WalletService) SetWalletLimit(ctx context.Context, limit *WalletLimit) error {
+	for _, value := range []*int64{limit.DailyDebit, limit.MonthlyDebit, limit.HourlyTransactions} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidLimit, *value)
+		}
+	}
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		if _, err := lockWallets(tx, limit.WalletId); err != nil {
+			return err
+		}
+		return tx.Omit("Wallet").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "wallet_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"daily_debit", "monthly_debit", "hourly_transactions", "updated_at"}),
+		}).Create(limit).Error
+	})
+}
+
+// WalletLimits mengembalikan batas yang berlaku untuk wallet, yaitu DefaultLimits yang ditimpa WalletLimit
+func (s *WalletService) WalletLimits(ctx context.Context, walletID string) (Limits, error) {
+	return s.walletLimits(s.db.WithContext(ctx), walletID)
+}
+
+func (s *WalletService) walletLimits(tx *gorm.DB, walletID string) (Limits, error) {
+	var limit WalletLimit
+	err := tx.Take(&limit, "wallet_id = ?", walletID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.DefaultLimits, nil
+	}
+	if err != nil {
+		return Limits{}, err
+	}
+	return limit.apply(s.DefaultLimits), nil
+}
+
+type debitUsage struct {
+	Daily   int64
+	Monthly int64
+	Hourly  int64
+}
+
+// checkLimits memastikan debit sebesar amount tidak melanggar batas wallet
+// harus dipanggil setelah wallet dikunci, sehingga debit lain ke wallet yang sama menunggu
+// sampai transaction ini selesai dan pemakaian yang dihitung tetap benar
+//
+// hari dan bulan dihitung menurut LimitLocation, sedangkan jumlah transaksi per jam memakai satu jam terakhir
+func (s *WalletService) checkLimits(tx *gorm.DB, wallet *Wallet, amount int64) error {
+	limits, err := s.walletLimits(tx, wallet.ID)
+	if err != nil {
+		return err
+	}
+	if limits.empty() {
+		return nil
+	}
+
+	loc := s.LimitLocation
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc).UTC()
+	hour := now.Add(-time.Hour).UTC()
+	since := month
+	if hour.Before(since) {
+		since = hour
+	}
+
+	// created_at di wallet_entries disimpan dalam UTC, lihat insertEntries
+	var usage debitUsage
+	err = tx.Model(&WalletEntry{}).
+		Select("COALESCE(SUM(CASE WHEN created_at >= ? THEN amount ELSE 0 END), 0) AS daily, "+
+			"COALESCE(SUM(CASE WHEN created_at >= ? THEN amount ELSE 0 END), 0) AS monthly, "+
+			"COALESCE(SUM(CASE WHEN created_at >= ? THEN 1 ELSE 0 END), 0) AS hourly", day, month, hour).
+		Where("account = ? AND direction = ? AND created_at >= ?", wallet.ID, Debit, since).
+		Scan(&usage).Error
+	if err != nil {
+		return err
+	}
+
+	checks := []struct {
+		rule   LimitRule
+		limit  int64
+		used   int64
+		amount int64
+	}{
+		{LimitHourlyTransactions, limits.HourlyTransactions, usage.Hourly, 1},
+		{LimitDailyDebit, limits.DailyDebit, usage.Daily, amount},
+		{LimitMonthlyDebit, limits.MonthlyDebit, usage.Monthly, amount},
+	}
+	for _, c := range checks {
+		if c.limit == 0 || c.used+c.amount <= c.limit {
+			continue
+		}
+		remaining := c.limit - c.used
+		if remaining < 0 {
+			remaining = 0
+		}
+		return &LimitExceededError{
+			WalletID:  wallet.ID,
+			Rule:      c.rule,
+			Currency:  wallet.Currency,
+			Limit:     c.limit,
+			Used:      c.used,
+			Amount:    c.amount,
+			Remaining: remaining,
+		}
+	}
+	return nil
+}
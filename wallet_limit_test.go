@@ -0,0 +1,105 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestWalletLimitDaily(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	service.DefaultLimits = Limits{DailyDebit: 5000}
+	ctx := context.Background()
+
+	assert.Nil(t, service.Transfer(ctx, "2", "3", 3000))
+	err := service.Withdraw(ctx, "2", 2500, "atm")
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	var limitErr *LimitExceededError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, LimitDailyDebit, limitErr.Rule)
+	assert.Equal(t, int64(5000), limitErr.Limit)
+	assert.Equal(t, int64(3000), limitErr.Used)
+	assert.Equal(t, int64(2000), limitErr.Remaining)
+	assert.Equal(t, "belajargorm: daily_debit limit IDR 50.00 exceeded for wallet 2: used IDR 30.00, amount IDR 25.00, remaining IDR 20.00", err.Error())
+	assert.Equal(t, int64(7000), walletBalance(t, db, "2"))
+
+	// credit tidak dihitung, dan debit kemarin tidak masuk batas harian
+	backdateLastGroup(t, db, "2", time.Now().Add(-24*time.Hour))
+	assert.Nil(t, service.Withdraw(ctx, "2", 2500, "atm"))
+	assert.Nil(t, service.Transfer(ctx, "2", "3", 2500))
+	assert.ErrorIs(t, service.Transfer(ctx, "2", "3", 1), ErrLimitExceeded)
+}
+
+func TestWalletLimitOverride(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	service.DefaultLimits = Limits{DailyDebit: 1000, MonthlyDebit: 8000}
+	ctx := context.Background()
+
+	// batas harian wallet 2 dinaikkan, batas bulanan tetap memakai default
+	assert.Nil(t, service.SetWalletLimit(ctx, &WalletLimit{WalletId: "2", DailyDebit: int64Ptr(0)}))
+	limits, err := service.WalletLimits(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, Limits{DailyDebit: 0, MonthlyDebit: 8000}, limits)
+
+	assert.Nil(t, service.Withdraw(ctx, "2", 6000, "atm"))
+	err = service.Withdraw(ctx, "2", 3000, "atm")
+	var limitErr *LimitExceededError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, LimitMonthlyDebit, limitErr.Rule)
+	assert.Equal(t, int64(2000), limitErr.Remaining)
+
+	// wallet lain tetap memakai default
+	assert.ErrorIs(t, service.Withdraw(ctx, "3", 1001, "atm"), ErrLimitExceeded)
+
+	// SetWalletLimit menggantikan batas sebelumnya
+	assert.Nil(t, service.SetWalletLimit(ctx, &WalletLimit{WalletId: "2", MonthlyDebit: int64Ptr(0)}))
+	limits, err = service.WalletLimits(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, Limits{DailyDebit: 1000, MonthlyDebit: 0}, limits)
+
+	assert.ErrorIs(t, service.SetWalletLimit(ctx, &WalletLimit{WalletId: "2", DailyDebit: int64Ptr(-1)}), ErrInvalidLimit)
+	assert.ErrorIs(t, service.SetWalletLimit(ctx, &WalletLimit{WalletId: "x"}), ErrWalletNotFound)
+}
+
+func TestWalletLimitVelocity(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 3, 10000)
+	service := NewWalletService(db)
+	ctx := context.Background()
+	assert.Nil(t, service.SetWalletLimit(ctx, &WalletLimit{WalletId: "2", HourlyTransactions: int64Ptr(2)}))
+
+	hold, err := service.Authorize(ctx, "2", 100, time.Hour)
+	assert.Nil(t, err)
+	assert.Nil(t, service.Transfer(ctx, "2", "3", 100))
+	assert.Nil(t, service.Withdraw(ctx, "2", 100, "atm"))
+
+	err = service.Capture(ctx, hold.ID, 100)
+	var limitErr *LimitExceededError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, LimitHourlyTransactions, limitErr.Rule)
+	assert.Equal(t, int64(0), limitErr.Remaining)
+	assert.Equal(t, "belajargorm: hourly_transactions limit 2 exceeded for wallet 2: used 2, remaining 0", err.Error())
+
+	backdateLastGroup(t, db, "2", time.Now().Add(-2*time.Hour))
+	assert.Nil(t, service.Capture(ctx, hold.ID, 100))
+
+	// deposit bukan debit
+	assert.Nil(t, service.Deposit(ctx, "2", 100, "topup"))
+	assert.ErrorIs(t, service.Withdraw(ctx, "2", 100, "atm"), ErrLimitExceeded)
+	assertLedgerConsistent(t, db)
+}
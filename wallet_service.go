@@ -0,0 +1,222 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidAmount       = errors.New("belajargorm: amount must be positive")
+	ErrInsufficientBalance = errors.New("belajargorm: insufficient balance")
+	ErrWalletNotFound      = errors.New("belajargorm: wallet not found")
+	ErrSameWallet          = errors.New("belajargorm: cannot transfer to the same wallet")
+)
+
+// InsufficientBalanceError dikembalikan jika saldo wallet yang tersedia tidak cukup
+// Balance adalah saldo yang tersedia, yaitu saldo dikurangi hold aktif
+// errors.Is(err, ErrInsufficientBalance) bernilai true
+type InsufficientBalanceError struct {
+	WalletID string
+	Currency string
+	Balance  int64
+	Amount   int64
+}
+
+func insufficientBalance(wallet *Wallet, available, amount int64) *InsufficientBalanceError {
+	return &InsufficientBalanceError{WalletID: wallet.ID, Currency: wallet.Currency, Balance: available, Amount: amount}
+}
+
+// checkAvailable memastikan saldo wallet dikurangi hold aktif cukup untuk amount
+func checkAvailable(tx *gorm.DB, wallet *Wallet, amount int64) error {
+	available, err := availableBalance(tx, wallet)
+	if err != nil {
+		return err
+	}
+	if available < amount {
+		return insufficientBalance(wallet, available, amount)
+	}
+	return nil
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("belajargorm: insufficient balance in wallet %s: balance %s, amount %s",
+		e.WalletID, Money{Amount: e.Balance, Currency: e.Currency}, Money{Amount: e.Amount, Currency: e.Currency})
+}
+
+func (e *InsufficientBalanceError) Is(target error) bool {
+	return target == ErrInsufficientBalance
+}
+
+// WalletService berisi operasi yang mengubah saldo Wallet
+type WalletService struct {
+	db *gorm.DB
+
+	// MaxAttempts adalah jumlah percobaan transaction jika terjadi deadlock atau serialization failure
+	MaxAttempts int
+
+	// DefaultLimits berlaku untuk wallet yang tidak punya WalletLimit, nilai kosong berarti tanpa batas
+	DefaultLimits Limits
+	// LimitLocation menentukan awal hari dan bulan untuk LimitDailyDebit dan LimitMonthlyDebit, nil berarti UTC
+	LimitLocation *time.Location
+	// ApprovalThresholds adalah jumlah maksimum per mata uang (minor unit) untuk Transfer, Deposit dan Withdraw
+	// yang langsung dijalankan, operasi yang lebih besar ditolak dengan ErrApprovalRequired
+	// dan harus diajukan lewat ProposeOperation
+	ApprovalThresholds map[string]int64
+
+	// approved bernilai true saat operasi dijalankan oleh ApproveOperation
+	approved bool
+}
+
+func NewWalletService(db *gorm.DB) *WalletService {
+	return &WalletService{db: db, MaxAttempts: defaultMaxAttempts}
+}
+
+// withDB mengembalikan salinan service yang memakai db lain, misalnya transaction yang sedang berjalan
+func (s *WalletService) withDB(db *gorm.DB) *WalletService {
+	clone := *s
+	clone.db = db
+	return &clone
+}
+
+func (s *WalletService) transaction(ctx context.Context, fc func(tx *gorm.DB) error) error {
+	return transaction(ctx, s.db, s.MaxAttempts, fc)
+}
+
+// lockWallets mengunci wallet dengan SELECT ... FOR UPDATE secara berurutan berdasarkan id
+// urutan yang selalu sama mencegah deadlock antara dua transfer yang berlawanan arah
+func lockWallets(tx *gorm.DB, ids ...string) (map[string]*Wallet, error) {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+
+	wallets := make(map[string]*Wallet, len(sorted))
+	for _, id := range sorted {
+		if _, ok := wallets[id]; ok {
+			continue
+		}
+		var wallet Wallet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&wallet, "id = ?", id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrWalletNotFound, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		wallets[id] = &wallet
+	}
+	return wallets, nil
+}
+
+// Transfer memindahkan saldo antar wallet secara atomic melalui ledger
+// kedua wallet dikunci dengan urutan yang sama, dan transaction diulang jika terjadi deadlock
+// amount dalam minor unit mata uang wallet asal, jika mata uang wallet tujuan berbeda
+// amount dikonversi memakai ExchangeRateAt
+func (s *WalletService) Transfer(ctx context.Context, fromWalletID, toWalletID string, amount int64) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidAmount, amount)
+	}
+	if fromWalletID == toWalletID {
+		return ErrSameWallet
+	}
+
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		wallets, err := lockWallets(tx, fromWalletID, toWalletID)
+		if err != nil {
+			return err
+		}
+
+		from, to := wallets[fromWalletID], wallets[toWalletID]
+		if err := checkDebit(from); err != nil {
+			return err
+		}
+		if err := checkCredit(to); err != nil {
+			return err
+		}
+		if err := s.checkApproval(OperationTransfer, from, amount); err != nil {
+			return err
+		}
+		if err := checkAvailable(tx, from, amount); err != nil {
+			return err
+		}
+		if err := s.checkLimits(tx, from, amount); err != nil {
+			return err
+		}
+		debit := Money{Amount: amount, Currency: from.Currency}
+		if from.Currency == to.Currency {
+			_, err = postEntries(tx, "transfer", transferEntries(from.ID, to.ID, debit))
+			return err
+		}
+
+		// transfer antar mata uang melewati AccountFX memakai kurs yang berlaku saat ini
+		rate, err := ExchangeRateAt(ctx, tx, from.Currency, to.Currency, time.Now())
+		if err != nil {
+			return err
+		}
+		credit, err := debit.Convert(to.Currency, rate)
+		if err != nil {
+			return err
+		}
+		if credit.Amount <= 0 {
+			return fmt.Errorf("%w: %s is zero after conversion to %s", ErrInvalidAmount, debit, to.Currency)
+		}
+		entries := append(transferEntries(from.ID, AccountFX, debit), transferEntries(AccountFX, to.ID, credit)...)
+		reference := fmt.Sprintf("transfer %s/%s %s", from.Currency, to.Currency, rate.FloatString(6))
+		_, err = postEntries(tx, reference, entries)
+		return err
+	})
+}
+
+// Deposit menambah saldo wallet dari luar sistem (AccountExternal)
+func (s *WalletService) Deposit(ctx context.Context, walletID string, amount int64, reference string) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidAmount, amount)
+	}
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		wallets, err := lockWallets(tx, walletID)
+		if err != nil {
+			return err
+		}
+		wallet := wallets[walletID]
+		if err := checkCredit(wallet); err != nil {
+			return err
+		}
+		if err := s.checkApproval(OperationDeposit, wallet, amount); err != nil {
+			return err
+		}
+		_, err = postEntries(tx, reference, transferEntries(AccountExternal, wallet.ID, Money{Amount: amount, Currency: wallet.Currency}))
+		return err
+	})
+}
+
+// Withdraw mengurangi saldo wallet ke luar sistem (AccountExternal)
+func (s *WalletService) Withdraw(ctx context.Context, walletID string, amount int64, reference string) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidAmount, amount)
+	}
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		wallets, err := lockWallets(tx, walletID)
+		if err != nil {
+			return err
+		}
+		wallet := wallets[walletID]
+		if err := checkDebit(wallet); err != nil {
+			return err
+		}
+		if err := s.checkApproval(OperationWithdraw, wallet, amount); err != nil {
+			return err
+		}
+		if err := checkAvailable(tx, wallet, amount); err != nil {
+			return err
+		}
+		if err := s.checkLimits(tx, wallet, amount); err != nil {
+			return err
+		}
+		_, err = postEntries(tx, reference, transferEntries(wallet.ID, AccountExternal, Money{Amount: amount, Currency: wallet.Currency}))
+		return err
+	})
+}
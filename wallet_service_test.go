@@ -0,0 +1,117 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// createWallets membuat wallet "2" sampai "n" milik user dengan id yang sama
+func createWallets(t *testing.T, db *gorm.DB, n int, balance int64) {
+	t.Helper()
+	for i := 2; i <= n; i++ {
+		id := strconv.Itoa(i)
+		err := db.Create(&Wallet{ID: id, UserId: id, Balance: balance}).Error
+		assert.Nil(t, err)
+	}
+}
+
+func walletBalance(t *testing.T, db *gorm.DB, id string) int64 {
+	t.Helper()
+	var wallet Wallet
+	assert.Nil(t, db.Take(&wallet, "id = ?", id).Error)
+	return wallet.Balance
+}
+
+func TestTransfer(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 0)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	err := service.Transfer(ctx, "1", "2", 250000)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(750000), walletBalance(t, db, "1"))
+	assert.Equal(t, int64(250000), walletBalance(t, db, "2"))
+
+	err = service.Transfer(ctx, "2", "1", 250001)
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	var insufficient *InsufficientBalanceError
+	assert.True(t, errors.As(err, &insufficient))
+	assert.Equal(t, "2", insufficient.WalletID)
+	assert.Equal(t, int64(250000), insufficient.Balance)
+
+	assert.ErrorIs(t, service.Transfer(ctx, "1", "2", 0), ErrInvalidAmount)
+	assert.ErrorIs(t, service.Transfer(ctx, "1", "2", -5), ErrInvalidAmount)
+	assert.ErrorIs(t, service.Transfer(ctx, "1", "1", 5), ErrSameWallet)
+	assert.ErrorIs(t, service.Transfer(ctx, "1", "404", 5), ErrWalletNotFound)
+
+	// saldo tidak berubah setelah transfer yang gagal
+	assert.Equal(t, int64(750000), walletBalance(t, db, "1"))
+	assert.Equal(t, int64(250000), walletBalance(t, db, "2"))
+	assertLedgerConsistent(t, db)
+}
+
+func TestTransferConcurrentConservesBalance(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 5, 1000000)
+	service := NewWalletService(db)
+	service.MaxAttempts = 20
+	ctx := context.Background()
+
+	const workers = 8
+	const transfers = 25
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*transfers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < transfers; i++ {
+				from := strconv.Itoa(r.Intn(5) + 1)
+				to := strconv.Itoa(r.Intn(5) + 1)
+				if from == to {
+					continue
+				}
+				err := service.Transfer(ctx, from, to, int64(r.Intn(400000)+1))
+				if err != nil && !errors.Is(err, ErrInsufficientBalance) {
+					errs <- err
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var total int64
+	var wallets []Wallet
+	assert.Nil(t, db.Find(&wallets).Error)
+	for _, wallet := range wallets {
+		assert.GreaterOrEqual(t, wallet.Balance, int64(0))
+		total += wallet.Balance
+	}
+	assert.Equal(t, int64(5*1000000), total)
+	assertLedgerConsistent(t, db)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(&pgconn.PgError{Code: "40P01"}))
+	assert.True(t, isRetryable(&pgconn.PgError{Code: "40001"}))
+	assert.False(t, isRetryable(&pgconn.PgError{Code: "23505"}))
+	assert.True(t, isRetryable(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	assert.False(t, isRetryable(ErrInsufficientBalance))
+}
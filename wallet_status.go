@@ -0,0 +1,160 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WalletStatus adalah status Wallet
+//
+//	active -> frozen -> active
+//	active/frozen -> closed (hanya jika saldo nol)
+type WalletStatus string
+
+const (
+	WalletActive WalletStatus = "active"
+	WalletFrozen WalletStatus = "frozen"
+	WalletClosed WalletStatus = "closed"
+)
+
+// perpindahan status yang diizinkan, closed adalah status akhir
+var walletTransitions = map[WalletStatus][]WalletStatus{
+	WalletActive: {WalletFrozen, WalletClosed},
+	WalletFrozen: {WalletActive, WalletClosed},
+}
+
+var (
+	ErrWalletFrozen            = errors.New("belajargorm: wallet is frozen")
+	ErrWalletClosed            = errors.New("belajargorm: wallet is closed")
+	ErrInvalidStatusTransition = errors.New("belajargorm: invalid wallet status transition")
+	ErrWalletNotEmpty          = errors.New("belajargorm: wallet balance is not zero")
+	ErrReasonRequired          = errors.New("belajargorm: reason is required")
+)
+
+// WalletStatusError dikembalikan jika operasi ditolak karena status wallet
+// errors.Is(err, ErrWalletFrozen) atau errors.Is(err, ErrWalletClosed) bernilai true sesuai Status
+type WalletStatusError struct {
+	WalletID  string
+	Status    WalletStatus
+	Operation string
+}
+
+func (e *WalletStatusError) Error() string {
+	return fmt.Sprintf("belajargorm: cannot %s wallet %s: wallet is %s", e.Operation, e.WalletID, e.Status)
+}
+
+func (e *WalletStatusError) Is(target error) bool {
+	switch target {
+	case ErrWalletFrozen:
+		return e.Status == WalletFrozen
+	case ErrWalletClosed:
+		return e.Status == WalletClosed
+	}
+	return false
+}
+
+// WalletStatusChange mencatat setiap perubahan status wallet beserta alasannya
+type WalletStatusChange struct {
+	ID         int64        `gorm:"primaryKey;column:id;autoIncrement"`
+	WalletId   string       `gorm:"column:wallet_id;size:100;not null;index"`
+	FromStatus WalletStatus `gorm:"column:from_status;size:20;not null"`
+	ToStatus   WalletStatus `gorm:"column:to_status;size:20;not null"`
+	Reason     string       `gorm:"column:reason;size:255;not null"`
+	ActorId    string       `gorm:"column:actor_id;size:100"`
+	CreatedAt  time.Time    `gorm:"column:created_at;autoCreateTime"`
+	Wallet     *Wallet      `gorm:"foreignKey:wallet_id;references:id"`
+}
+
+func (c *WalletStatusChange) TableName() string {
+	return "wallet_status_changes"
+}
+
+// checkDebit menolak pengurangan saldo dari wallet yang frozen atau closed
+func checkDebit(wallet *Wallet) error {
+	if wallet.Status != WalletActive {
+		return &WalletStatusError{WalletID: wallet.ID, Status: wallet.Status, Operation: "debit"}
+	}
+	return nil
+}
+
+// checkCredit menolak penambahan saldo ke wallet yang closed, wallet frozen masih boleh menerima
+func checkCredit(wallet *Wallet) error {
+	if wallet.Status == WalletClosed {
+		return &WalletStatusError{WalletID: wallet.ID, Status: wallet.Status, Operation: "credit"}
+	}
+	return nil
+}
+
+func canTransition(from, to WalletStatus) bool {
+	for _, next := range walletTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeStatus memindahkan status wallet sesuai walletTransitions dan mencatatnya di wallet_status_changes
+// dan user_logs, actor diambil dari context, lihat WithActor
+func (s *WalletService) ChangeStatus(ctx context.Context, walletID string, to WalletStatus, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrReasonRequired
+	}
+
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		wallets, err := lockWallets(tx, walletID)
+		if err != nil {
+			return err
+		}
+		wallet := wallets[walletID]
+		if !canTransition(wallet.Status, to) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, wallet.Status, to)
+		}
+		if to == WalletClosed && wallet.Balance != 0 {
+			return fmt.Errorf("%w: %s has %s", ErrWalletNotEmpty, wallet.ID, wallet.BalanceMoney())
+		}
+
+		// status tidak bisa diubah lewat model Wallet (<-:create), sama seperti balance
+		err = tx.Table("wallets").Where("id = ?", wallet.ID).Updates(map[string]interface{}{
+			"status":     to,
+			"updated_at": tx.NowFunc(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		changes := map[string]AuditChange{"status": {Old: wallet.Status, New: to}}
+		if err := writeWalletLog(tx, wallet.ID, wallet.UserId, changes); err != nil {
+			return err
+		}
+
+		actor, _ := ActorFromContext(ctx)
+		return tx.Create(&WalletStatusChange{
+			WalletId:   wallet.ID,
+			FromStatus: wallet.Status,
+			ToStatus:   to,
+			Reason:     reason,
+			ActorId:    actor,
+		}).Error
+	})
+}
+
+// Freeze menahan semua debit dari wallet, misalnya selama investigasi
+func (s *WalletService) Freeze(ctx context.Context, walletID, reason string) error {
+	return s.ChangeStatus(ctx, walletID, WalletFrozen, reason)
+}
+
+// Unfreeze mengaktifkan kembali wallet yang frozen
+func (s *WalletService) Unfreeze(ctx context.Context, walletID, reason string) error {
+	return s.ChangeStatus(ctx, walletID, WalletActive, reason)
+}
+
+// Close menutup wallet secara permanen, saldo wallet harus nol
+func (s *WalletService) Close(ctx context.Context, walletID, reason string) error {
+	return s.ChangeStatus(ctx, walletID, WalletClosed, reason)
+}
@@ -0,0 +1,93 @@
+package belajargorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletFreeze(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 1000)
+	service := NewWalletService(db)
+	ctx := WithActor(context.Background(), "2")
+
+	hold, err := service.Authorize(ctx, "1", 1000, time.Hour)
+	assert.Nil(t, err)
+
+	assert.ErrorIs(t, service.Freeze(ctx, "1", " "), ErrReasonRequired)
+	assert.Nil(t, service.Freeze(ctx, "1", "investigasi fraud"))
+	assert.ErrorIs(t, service.Freeze(ctx, "1", "lagi"), ErrInvalidStatusTransition)
+
+	// debit ditolak, credit tetap diterima
+	err = service.Transfer(ctx, "1", "2", 100)
+	var statusErr *WalletStatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, WalletFrozen, statusErr.Status)
+	assert.ErrorIs(t, err, ErrWalletFrozen)
+	assert.ErrorIs(t, service.Withdraw(ctx, "1", 100, "tarik"), ErrWalletFrozen)
+	_, err = service.Authorize(ctx, "1", 100, time.Hour)
+	assert.ErrorIs(t, err, ErrWalletFrozen)
+	assert.ErrorIs(t, service.Capture(ctx, hold.ID, 100), ErrWalletFrozen)
+	assert.Nil(t, service.Transfer(ctx, "2", "1", 100))
+	assert.Nil(t, service.Deposit(ctx, "1", 100, "topup"))
+
+	// status tidak bisa diubah lewat Save
+	var wallet Wallet
+	assert.Nil(t, db.Take(&wallet, "id = ?", "1").Error)
+	wallet.Status = WalletActive
+	assert.Nil(t, db.Save(&wallet).Error)
+	assert.Nil(t, db.Take(&wallet, "id = ?", "1").Error)
+	assert.Equal(t, WalletFrozen, wallet.Status)
+
+	assert.Nil(t, service.Unfreeze(ctx, "1", "investigasi selesai"))
+	assert.Nil(t, service.Transfer(ctx, "1", "2", 100))
+
+	var changes []WalletStatusChange
+	assert.Nil(t, db.Where("wallet_id = ?", "1").Order("id").Find(&changes).Error)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, WalletActive, changes[0].FromStatus)
+	assert.Equal(t, WalletFrozen, changes[0].ToStatus)
+	assert.Equal(t, "investigasi fraud", changes[0].Reason)
+	assert.Equal(t, "2", changes[0].ActorId)
+	assert.Equal(t, WalletActive, changes[1].ToStatus)
+
+	// perubahan status juga dicatat di user_logs
+	var logs []UserLog
+	assert.Nil(t, db.Where("entity = ? AND entity_id = ? AND action = ? AND changes LIKE ?", "wallets", "1", AuditUpdate, "%status%").Order("id").Find(&logs).Error)
+	if assert.Equal(t, 2, len(logs)) {
+		assert.Equal(t, "2", logs[0].ActorId)
+		assert.Equal(t, "1", logs[0].UserId)
+		assert.Equal(t, AuditChange{Old: string(WalletActive), New: string(WalletFrozen)}, auditChanges(t, logs[0])["status"])
+		assert.Equal(t, AuditChange{Old: string(WalletFrozen), New: string(WalletActive)}, auditChanges(t, logs[1])["status"])
+	}
+}
+
+func TestWalletClose(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	createWallets(t, db, 2, 500)
+	service := NewWalletService(db)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, service.Close(ctx, "2", "permintaan user"), ErrWalletNotEmpty)
+	assert.Nil(t, service.Freeze(ctx, "2", "permintaan user"))
+	assert.ErrorIs(t, service.Close(ctx, "2", "permintaan user"), ErrWalletNotEmpty)
+
+	// saldo wallet frozen dikosongkan lewat unfreeze lalu transfer
+	assert.Nil(t, service.Unfreeze(ctx, "2", "pengosongan saldo"))
+	assert.Nil(t, service.Transfer(ctx, "2", "1", 500))
+	assert.Nil(t, service.Close(ctx, "2", "permintaan user"))
+
+	assert.ErrorIs(t, service.Deposit(ctx, "2", 100, "topup"), ErrWalletClosed)
+	assert.ErrorIs(t, service.Transfer(ctx, "1", "2", 100), ErrWalletClosed)
+	assert.ErrorIs(t, service.Unfreeze(ctx, "2", "buka lagi"), ErrInvalidStatusTransition)
+	assert.ErrorIs(t, service.Freeze(ctx, "404", "tidak ada"), ErrWalletNotFound)
+
+	assert.NotNil(t, db.Create(&Wallet{ID: "3", UserId: "3", Status: WalletClosed}).Error)
+	assertLedgerConsistent(t, db)
+}